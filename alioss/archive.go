@@ -0,0 +1,457 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// 支持的压缩包格式
+const (
+	ArchiveTarGz = "tar.gz"
+	ArchiveZip   = "zip"
+)
+
+// zipReadAheadSize 解压zip时每次范围请求读取的大小
+const zipReadAheadSize = 4 * 1024 * 1024
+
+// UploadArchive 将目录打包压缩后以流的方式上传为一个OSS文件，不生成临时文件
+// 打包时使用与UploadDirectory相同的排除模式和符号链接处理方式
+func (c *OSSClient) UploadArchive(ctx context.Context, localDirPath, ossPath string, options *UploadOptions) error {
+	format := options.Archive
+	if format != ArchiveTarGz && format != ArchiveZip {
+		return fmt.Errorf("不支持的压缩格式: %s，可选 %s 或 %s", format, ArchiveTarGz, ArchiveZip)
+	}
+
+	fileInfo, err := os.Stat(localDirPath)
+	if err != nil {
+		return fmt.Errorf("读取文件信息失败: %v", err)
+	}
+	if !fileInfo.IsDir() {
+		return fmt.Errorf("打包上传只支持目录: %s", localDirPath)
+	}
+
+	// 未指定OSS路径或指定的是目录时，使用本地目录名作为文件名
+	if ossPath == "" || strings.HasSuffix(ossPath, "/") {
+		absPath, err := filepath.Abs(localDirPath)
+		if err != nil {
+			return fmt.Errorf("获取绝对路径失败: %v", err)
+		}
+		ossPath += filepath.Base(absPath) + "." + format
+	}
+
+	fmt.Printf("开始打包上传目录: %s 到 %s (%s)\n", localDirPath, ossPath, format)
+	if len(options.ExcludePatterns) > 0 {
+		fmt.Printf("使用 %d 个排除模式\n", len(options.ExcludePatterns))
+	}
+
+	pr, pw := io.Pipe()
+
+	var fileCount, excludeCount int
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var err error
+		if format == ArchiveZip {
+			fileCount, excludeCount, err = writeZip(pw, localDirPath, options)
+		} else {
+			fileCount, excludeCount, err = writeTarGz(pw, localDirPath, options)
+		}
+		pw.CloseWithError(err)
+	}()
+
+	err = c.UploadStream(ctx, pr, ossPath, options)
+	// 关闭读端，上传失败时让打包协程退出
+	pr.CloseWithError(fmt.Errorf("上传已中止"))
+	<-done
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("成功打包上传 %d 个文件到 %s", fileCount, ossPath)
+	if excludeCount > 0 {
+		fmt.Printf("，已排除 %d 个文件", excludeCount)
+	}
+	fmt.Println()
+	return nil
+}
+
+// writeTarGz 将目录打包为tar.gz写入w，返回打包的文件数和排除的文件数
+func writeTarGz(w io.Writer, localDirPath string, options *UploadOptions) (int, int, error) {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	fileCount := 0
+	excludeCount, err := walkLocalDir(localDirPath, options, func(file *localFile) error {
+		header, err := tar.FileInfoHeader(file.info, file.linkTarget)
+		if err != nil {
+			return fmt.Errorf("生成打包信息失败: %s - %v", file.path, err)
+		}
+		header.Name = file.relPath
+
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if file.linkTarget == "" {
+			if err := copyFileTo(tw, file.path); err != nil {
+				return fmt.Errorf("打包文件 %s 失败: %v", file.path, err)
+			}
+		}
+
+		fileCount++
+		return nil
+	})
+	if err != nil {
+		return fileCount, excludeCount, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return fileCount, excludeCount, err
+	}
+	return fileCount, excludeCount, gw.Close()
+}
+
+// writeZip 将目录打包为zip写入w，返回打包的文件数和排除的文件数
+func writeZip(w io.Writer, localDirPath string, options *UploadOptions) (int, int, error) {
+	zw := zip.NewWriter(w)
+
+	fileCount := 0
+	excludeCount, err := walkLocalDir(localDirPath, options, func(file *localFile) error {
+		header, err := zip.FileInfoHeader(file.info)
+		if err != nil {
+			return fmt.Errorf("生成打包信息失败: %s - %v", file.path, err)
+		}
+		header.Name = file.relPath
+		header.Method = zip.Deflate
+
+		// zip中的符号链接以链接目标作为内容
+		if file.linkTarget != "" {
+			header.Method = zip.Store
+			header.SetMode(os.ModeSymlink | 0777)
+		}
+
+		fw, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+
+		if file.linkTarget != "" {
+			if _, err := io.WriteString(fw, file.linkTarget); err != nil {
+				return err
+			}
+		} else if err := copyFileTo(fw, file.path); err != nil {
+			return fmt.Errorf("打包文件 %s 失败: %v", file.path, err)
+		}
+
+		fileCount++
+		return nil
+	})
+	if err != nil {
+		return fileCount, excludeCount, err
+	}
+
+	return fileCount, excludeCount, zw.Close()
+}
+
+// copyFileTo 将本地文件内容写入w
+func copyFileTo(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// archiveFormat 根据文件名判断压缩包格式
+func archiveFormat(ossPath string) string {
+	lower := strings.ToLower(ossPath)
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return ArchiveTarGz
+	case strings.HasSuffix(lower, ".tar"):
+		return "tar"
+	case strings.HasSuffix(lower, ".zip"):
+		return ArchiveZip
+	}
+	return ""
+}
+
+// DownloadAndExtract 以流的方式下载压缩包并解压到本地目录
+// 压缩包中指向目录外的路径（绝对路径、".."或经由符号链接）会被拒绝
+func (c *OSSClient) DownloadAndExtract(ctx context.Context, ossPath, localPath string) error {
+	// 标准化OSS路径，去除前导斜杠
+	ossPath = strings.TrimPrefix(ossPath, "/")
+
+	format := archiveFormat(ossPath)
+	if format == "" {
+		return fmt.Errorf("无法识别的压缩格式: %s，支持 .tar.gz、.tgz、.tar 和 .zip", ossPath)
+	}
+
+	if err := os.MkdirAll(localPath, 0755); err != nil {
+		return fmt.Errorf("创建本地目录失败: %v", err)
+	}
+
+	fmt.Printf("开始下载并解压: %s 到 %s\n", ossPath, localPath)
+
+	var count int
+	var err error
+	if format == ArchiveZip {
+		count, err = c.extractZip(ctx, ossPath, localPath)
+	} else {
+		count, err = c.extractTar(ctx, ossPath, localPath, format == ArchiveTarGz)
+	}
+	if err != nil {
+		return fmt.Errorf("解压失败: %v", err)
+	}
+
+	fmt.Printf("成功解压 %d 个文件到 %s\n", count, localPath)
+	return nil
+}
+
+// extractTar 流式下载并解压tar或tar.gz
+func (c *OSSClient) extractTar(ctx context.Context, ossPath, localPath string, gzipped bool) (int, error) {
+	body, err := c.bucket.GetObject(ossPath, oss.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+
+	var reader io.Reader = body
+	if gzipped {
+		gr, err := gzip.NewReader(body)
+		if err != nil {
+			return 0, err
+		}
+		defer gr.Close()
+		reader = gr
+	}
+
+	tr := tar.NewReader(reader)
+	count := 0
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return count, err
+		}
+
+		target := filepath.Join(localPath, filepath.FromSlash(header.Name))
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := ensureLocalDir(localPath, target); err != nil {
+				return count, err
+			}
+			continue
+		case tar.TypeReg:
+			if err := extractFile(localPath, target, tr, header.FileInfo().Mode()); err != nil {
+				return count, err
+			}
+		case tar.TypeSymlink:
+			if err := extractSymlink(localPath, target, header.Linkname); err != nil {
+				return count, err
+			}
+		case tar.TypeLink:
+			// 链接目标所在的目录解析符号链接后也必须位于目标目录内，
+			// 目标本身不能是符号链接，否则会把目录外的文件链接进来
+			linkTarget := filepath.Join(localPath, filepath.FromSlash(header.Linkname))
+			if err := prepareLocalPath(localPath, linkTarget); err != nil {
+				return count, fmt.Errorf("硬链接指向目标目录外: %s", header.Name)
+			}
+			if info, err := os.Lstat(linkTarget); err == nil && info.Mode()&os.ModeSymlink != 0 {
+				return count, fmt.Errorf("硬链接指向符号链接: %s", header.Name)
+			}
+			if err := prepareLocalPath(localPath, target); err != nil {
+				return count, err
+			}
+			os.Remove(target)
+			if err := os.Link(linkTarget, target); err != nil {
+				return count, err
+			}
+		default:
+			// 跳过设备文件等特殊类型
+			continue
+		}
+
+		// 符号链接和硬链接不设置时间，避免修改链接指向的文件
+		if header.Typeflag == tar.TypeReg {
+			os.Chtimes(target, header.ModTime, header.ModTime)
+		}
+		count++
+		fmt.Printf("已解压: %s\n", header.Name)
+	}
+
+	return count, nil
+}
+
+// extractZip 通过范围请求读取OSS上的zip并解压，不需要先下载整个文件
+func (c *OSSClient) extractZip(ctx context.Context, ossPath, localPath string) (int, error) {
+	meta, err := c.bucket.GetObjectDetailedMeta(ossPath)
+	if err != nil {
+		return 0, err
+	}
+	size, err := strconv.ParseInt(meta.Get(oss.HTTPHeaderContentLength), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("无效的文件大小: %v", err)
+	}
+
+	zr, err := zip.NewReader(&objectReaderAt{ctx: ctx, bucket: c.bucket, key: ossPath, size: size}, size)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, f := range zr.File {
+		if err := ctx.Err(); err != nil {
+			return count, err
+		}
+		target := filepath.Join(localPath, filepath.FromSlash(f.Name))
+		mode := f.Mode()
+
+		if mode.IsDir() {
+			if err := ensureLocalDir(localPath, target); err != nil {
+				return count, err
+			}
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return count, err
+		}
+
+		if mode&os.ModeSymlink != 0 {
+			var linkTarget []byte
+			linkTarget, err = io.ReadAll(rc)
+			if err == nil {
+				err = extractSymlink(localPath, target, string(linkTarget))
+			}
+		} else {
+			err = extractFile(localPath, target, rc, mode)
+			if err == nil {
+				os.Chtimes(target, f.Modified, f.Modified)
+			}
+		}
+		rc.Close()
+		if err != nil {
+			return count, err
+		}
+
+		count++
+		fmt.Printf("已解压: %s\n", f.Name)
+	}
+
+	return count, nil
+}
+
+// extractFile 将r的内容写入root目录下的target文件
+func extractFile(root, target string, r io.Reader, mode os.FileMode) error {
+	if err := prepareLocalPath(root, target); err != nil {
+		return err
+	}
+
+	// 先删除已存在的文件，避免通过已存在的符号链接写到其它位置
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	untrack := trackPartial(target)
+	defer untrack()
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode.Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		// 下载中断或出错时不保留不完整的文件
+		f.Close()
+		os.Remove(target)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	// 创建文件时的权限受umask影响，需要重新设置
+	return os.Chmod(target, mode.Perm())
+}
+
+// extractSymlink 在root目录下创建符号链接，之后写入的文件会经过ensureLocalDir检查不会通过链接写到目录外
+func extractSymlink(root, target, linkTarget string) error {
+	if err := prepareLocalPath(root, target); err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Symlink(linkTarget, target)
+}
+
+// objectReaderAt 通过范围请求随机读取OSS文件，并缓存预读的数据块
+type objectReaderAt struct {
+	ctx    context.Context
+	bucket *oss.Bucket
+	key    string
+	size   int64
+
+	bufOffset int64
+	buf       []byte
+}
+
+// ReadAt 实现io.ReaderAt，zip.Reader只在单个协程中顺序使用，无需加锁
+func (r *objectReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off >= r.size {
+		return 0, io.EOF
+	}
+
+	n := 0
+	for n < len(p) && off < r.size {
+		// 命中缓存
+		if off >= r.bufOffset && off < r.bufOffset+int64(len(r.buf)) {
+			copied := copy(p[n:], r.buf[off-r.bufOffset:])
+			n += copied
+			off += int64(copied)
+			continue
+		}
+
+		end := off + zipReadAheadSize
+		if want := off + int64(len(p)-n); want > end {
+			end = want
+		}
+		if end > r.size {
+			end = r.size
+		}
+
+		body, err := r.bucket.GetObject(r.key, oss.Range(off, end-1), oss.WithContext(r.ctx))
+		if err != nil {
+			return n, err
+		}
+		buf := make([]byte, end-off)
+		_, err = io.ReadFull(body, buf)
+		body.Close()
+		if err != nil {
+			return n, err
+		}
+		r.bufOffset = off
+		r.buf = buf
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 审计日志记录的操作类型
+const (
+	AuditPut          = "put"           // 上传文件
+	AuditDelete       = "delete"        // 删除文件
+	AuditUndelete     = "undelete"      // 移除删除标记
+	AuditMove         = "move"          // 服务端移动文件（回收站）
+	AuditCopy         = "copy"          // 复制文件到其它位置
+	AuditTag          = "tag"           // 修改对象标签
+	AuditBucketCreate = "bucket-create" // 创建Bucket
+	AuditBucketDelete = "bucket-delete" // 删除Bucket
+	AuditBucketConfig = "bucket-config" // 修改Bucket配置
+)
+
+// AuditEntry 审计日志中的一条记录，每行一个JSON
+type AuditEntry struct {
+	Time      time.Time `json:"time"`
+	User      string    `json:"user"`
+	Host      string    `json:"host,omitempty"`
+	Profile   string    `json:"profile,omitempty"`
+	Bucket    string    `json:"bucket"`
+	Command   string    `json:"command"`
+	Op        string    `json:"op"`
+	Keys      []string  `json:"keys,omitempty"`
+	Dest      string    `json:"dest,omitempty"`
+	VersionID string    `json:"versionId,omitempty"`
+	Detail    string    `json:"detail,omitempty"`
+	Bytes     int64     `json:"bytes,omitempty"`
+	Result    string    `json:"result"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// auditLogger 将修改操作追加写入本地JSONL审计日志
+type auditLogger struct {
+	mu      sync.Mutex
+	path    string
+	user    string
+	host    string
+	profile string
+	command string
+	warned  bool
+}
+
+// defaultAuditLogPath 默认的审计日志路径
+func defaultAuditLogPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(homeDir, ".alioss_audit.jsonl")
+}
+
+// newAuditLogger 创建审计日志记录器，path为空时使用默认路径
+func newAuditLogger(path, profile string) *auditLogger {
+	if path == "" {
+		path = defaultAuditLogPath()
+	}
+	host, _ := os.Hostname()
+	return &auditLogger{
+		path:    path,
+		user:    currentUser(),
+		host:    host,
+		profile: profile,
+	}
+}
+
+// currentUser 获取当前用户名，通过sudo运行时记录原始用户
+func currentUser() string {
+	name := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	if sudoUser := os.Getenv("SUDO_USER"); sudoUser != "" && sudoUser != name {
+		name = sudoUser + " (sudo " + name + ")"
+	}
+	return name
+}
+
+// audit 记录一次修改操作，entry中只需填写操作相关的字段，Bucket为空时使用当前Bucket
+// 同时计入完成钩子的传输统计
+func (c *OSSClient) audit(entry AuditEntry, err error) {
+	c.auditContext(context.Background(), entry, err)
+}
+
+// auditContext 同audit，在调度器的任务中调用时每个任务只计入一次统计
+func (c *OSSClient) auditContext(ctx context.Context, entry AuditEntry, err error) {
+	if entry.Bucket == "" {
+		entry.Bucket = c.config.Bucket
+	}
+	c.stats.addContext(ctx, entry, err)
+
+	l := c.auditLog
+	if l == nil || l.path == "" {
+		return
+	}
+
+	entry.Time = time.Now()
+	entry.User = l.user
+	entry.Host = l.host
+	entry.Profile = l.profile
+	entry.Command = l.command
+	entry.Result = "ok"
+	if err != nil {
+		entry.Result = "error"
+		entry.Error = err.Error()
+	}
+
+	data, marshalErr := json.Marshal(entry)
+	if marshalErr != nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	file, openErr := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if openErr == nil {
+		_, openErr = file.Write(append(data, '\n'))
+		file.Close()
+	}
+	// 写审计日志失败不影响操作本身，只提示一次
+	if openErr != nil && !l.warned {
+		l.warned = true
+		fmt.Fprintf(os.Stderr, "警告: 写入审计日志失败: %v\n", openErr)
+	}
+}
+
+// HistoryFilter 查询审计日志的条件
+type HistoryFilter struct {
+	Command string    // 命令或操作类型
+	Prefix  string    // 至少一个文件以该前缀开头
+	User    string    // 用户名包含该字符串
+	Since   time.Time // 不早于该时间
+	Until   time.Time // 早于该时间
+	Errors  bool      // 只显示失败的操作
+	Limit   int       // 只显示最近的N条
+}
+
+// match 判断记录是否满足条件
+func (f *HistoryFilter) match(entry *AuditEntry) bool {
+	if f.Command != "" && entry.Command != f.Command && entry.Op != f.Command {
+		return false
+	}
+	if f.User != "" && !strings.Contains(entry.User, f.User) {
+		return false
+	}
+	if !f.Since.IsZero() && entry.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !entry.Time.Before(f.Until) {
+		return false
+	}
+	if f.Errors && entry.Result == "ok" {
+		return false
+	}
+	if f.Prefix != "" {
+		prefix := strings.TrimPrefix(f.Prefix, "/")
+		matched := strings.HasPrefix(entry.Dest, prefix)
+		for _, key := range entry.Keys {
+			if strings.HasPrefix(key, prefix) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// ReadHistory 读取审计日志中满足条件的记录，按时间顺序返回
+func ReadHistory(path string, filter *HistoryFilter) ([]AuditEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("读取审计日志失败: %v", err)
+	}
+	defer file.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(file)
+	// 批量删除的记录可能很长
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var entry AuditEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			fmt.Fprintf(os.Stderr, "警告: 审计日志第 %d 行格式错误，已跳过\n", lineNumber)
+			continue
+		}
+		if filter.match(&entry) {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取审计日志失败: %v", err)
+	}
+
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[len(entries)-filter.Limit:]
+	}
+	return entries, nil
+}
+
+// printHistory 输出审计记录
+func printHistory(entries []AuditEntry) {
+	if len(entries) == 0 {
+		fmt.Println("没有匹配的记录")
+		return
+	}
+
+	for _, entry := range entries {
+		target := ""
+		if len(entry.Keys) > 0 {
+			target = entry.Keys[0]
+			if len(entry.Keys) > 1 {
+				target += fmt.Sprintf(" 等%d个文件", len(entry.Keys))
+			}
+		}
+		if entry.Dest != "" {
+			target += " -> " + entry.Dest
+		}
+
+		fmt.Printf("%s  %s  %s/%s  %s:%s  %s", entry.Time.Local().Format("2006-01-02 15:04:05"), entry.User,
+			entry.Command, entry.Op, profileName(entry.Profile), entry.Bucket, target)
+		if entry.Bytes > 0 {
+			fmt.Printf("  %s", formatSize(entry.Bytes))
+		}
+		if entry.Detail != "" {
+			fmt.Printf("  [%s]", entry.Detail)
+		}
+		if entry.Result != "ok" {
+			fmt.Printf("  失败: %s", entry.Error)
+		}
+		fmt.Println()
+	}
+}
+
+// profileName 显示用的配置名称
+func profileName(profile string) string {
+	if profile == "" {
+		return "default"
+	}
+	return profile
+}
+
+// historyCommand 处理 history 命令
+func historyCommand(client *OSSClient, p *parsedArgs) error {
+	filter := &HistoryFilter{
+		Command: p.str("command", ""),
+		Prefix:  p.str("prefix", ""),
+		User:    p.str("user", ""),
+		Limit:   p.int("limit", 0),
+		Errors:  p.has("errors"),
+	}
+	asJSON := p.has("json")
+
+	var err error
+	if p.has("since") {
+		if filter.Since, err = parseTimeArg(p.str("since", "")); err != nil {
+			return err
+		}
+	}
+	if p.has("until") {
+		if filter.Until, err = parseTimeArg(p.str("until", "")); err != nil {
+			return err
+		}
+	}
+
+	entries, err := ReadHistory(client.auditLog.path, filter)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		for _, entry := range entries {
+			if err := encoder.Encode(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	printHistory(entries)
+	return nil
+}
@@ -0,0 +1,554 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// LifecycleConfig 生命周期规则的JSON格式
+type LifecycleConfig struct {
+	Rules []LifecycleRuleConfig `json:"rules"`
+}
+
+// LifecycleRuleConfig 单条生命周期规则
+type LifecycleRuleConfig struct {
+	ID                              string                `json:"id,omitempty"`
+	Prefix                          string                `json:"prefix"`
+	Enabled                         bool                  `json:"enabled"`
+	Tags                            map[string]string     `json:"tags,omitempty"`
+	ExpirationDays                  int                   `json:"expirationDays,omitempty"`
+	ExpirationCreatedBeforeDate     string                `json:"expirationCreatedBeforeDate,omitempty"`
+	ExpiredObjectDeleteMarker       bool                  `json:"expiredObjectDeleteMarker,omitempty"`
+	Transitions                     []LifecycleTransition `json:"transitions,omitempty"`
+	AbortMultipartUploadDays        int                   `json:"abortMultipartUploadDays,omitempty"`
+	NoncurrentVersionExpirationDays int                   `json:"noncurrentVersionExpirationDays,omitempty"`
+	NoncurrentVersionTransitions    []LifecycleTransition `json:"noncurrentVersionTransitions,omitempty"`
+}
+
+// LifecycleTransition 存储类型转换规则
+type LifecycleTransition struct {
+	Days         int    `json:"days"`
+	StorageClass string `json:"storageClass"`
+}
+
+// CORSConfig 跨域规则的JSON格式
+type CORSConfig struct {
+	Rules []CORSRuleConfig `json:"rules"`
+}
+
+// CORSRuleConfig 单条跨域规则
+type CORSRuleConfig struct {
+	AllowedOrigins []string `json:"allowedOrigins"`
+	AllowedMethods []string `json:"allowedMethods"`
+	AllowedHeaders []string `json:"allowedHeaders,omitempty"`
+	ExposeHeaders  []string `json:"exposeHeaders,omitempty"`
+	MaxAgeSeconds  int      `json:"maxAgeSeconds,omitempty"`
+}
+
+// RefererConfig 防盗链白名单的JSON格式
+type RefererConfig struct {
+	AllowEmptyReferer bool     `json:"allowEmptyReferer"`
+	Referers          []string `json:"referers"`
+}
+
+// WebsiteConfig 静态网站托管配置的JSON格式
+type WebsiteConfig struct {
+	IndexDocument string `json:"indexDocument"`
+	ErrorDocument string `json:"errorDocument,omitempty"`
+}
+
+// BucketCreateOptions 创建Bucket的选项
+type BucketCreateOptions struct {
+	ACL            string // private、public-read、public-read-write
+	StorageClass   string // Standard、IA、Archive、ColdArchive
+	RedundancyType string // LRS、ZRS
+}
+
+// ListBuckets 列出账号下的所有Bucket
+func (c *OSSClient) ListBuckets() ([]oss.BucketProperties, error) {
+	marker := ""
+	var buckets []oss.BucketProperties
+
+	for {
+		lsRes, err := c.client.ListBuckets(oss.Marker(marker))
+		if err != nil {
+			return nil, fmt.Errorf("列举Bucket失败: %v", err)
+		}
+
+		buckets = append(buckets, lsRes.Buckets...)
+
+		if lsRes.IsTruncated {
+			marker = lsRes.NextMarker
+		} else {
+			break
+		}
+	}
+
+	return buckets, nil
+}
+
+// CreateBucket 创建一个新的Bucket
+func (c *OSSClient) CreateBucket(name string, options *BucketCreateOptions) error {
+	var ossOptions []oss.Option
+	if options != nil {
+		if options.ACL != "" {
+			ossOptions = append(ossOptions, oss.ACL(oss.ACLType(options.ACL)))
+		}
+		if options.StorageClass != "" {
+			ossOptions = append(ossOptions, oss.StorageClass(oss.StorageClassType(options.StorageClass)))
+		}
+		if options.RedundancyType != "" {
+			ossOptions = append(ossOptions, oss.RedundancyType(oss.DataRedundancyType(options.RedundancyType)))
+		}
+	}
+
+	err := c.client.CreateBucket(name, ossOptions...)
+	c.audit(AuditEntry{Op: AuditBucketCreate, Bucket: name}, err)
+	if err != nil {
+		return fmt.Errorf("创建Bucket失败: %v", err)
+	}
+
+	return nil
+}
+
+// GetBucketInfo 获取Bucket的详细信息
+func (c *OSSClient) GetBucketInfo(name string) (oss.BucketInfo, error) {
+	res, err := c.client.GetBucketInfo(name)
+	if err != nil {
+		return oss.BucketInfo{}, fmt.Errorf("获取Bucket信息失败: %v", err)
+	}
+
+	return res.BucketInfo, nil
+}
+
+// DeleteBucket 删除Bucket（Bucket必须为空）
+func (c *OSSClient) DeleteBucket(name string) error {
+	err := c.client.DeleteBucket(name)
+	c.audit(AuditEntry{Op: AuditBucketDelete, Bucket: name}, err)
+	if err != nil {
+		return fmt.Errorf("删除Bucket失败: %v", err)
+	}
+
+	return nil
+}
+
+// GetLifecycle 获取Bucket的生命周期规则
+func (c *OSSClient) GetLifecycle(name string) (*LifecycleConfig, error) {
+	res, err := c.client.GetBucketLifecycle(name)
+	if isConfigNotFound(err) {
+		// 未配置时返回空配置，便于作为模板编辑
+		return &LifecycleConfig{Rules: []LifecycleRuleConfig{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取生命周期规则失败: %v", err)
+	}
+
+	config := &LifecycleConfig{Rules: []LifecycleRuleConfig{}}
+	for _, rule := range res.Rules {
+		r := LifecycleRuleConfig{
+			ID:      rule.ID,
+			Prefix:  rule.Prefix,
+			Enabled: rule.Status == "Enabled",
+		}
+		if len(rule.Tags) > 0 {
+			r.Tags = make(map[string]string)
+			for _, tag := range rule.Tags {
+				r.Tags[tag.Key] = tag.Value
+			}
+		}
+		if rule.Expiration != nil {
+			r.ExpirationDays = rule.Expiration.Days
+			r.ExpirationCreatedBeforeDate = rule.Expiration.CreatedBeforeDate
+			if rule.Expiration.ExpiredObjectDeleteMarker != nil {
+				r.ExpiredObjectDeleteMarker = *rule.Expiration.ExpiredObjectDeleteMarker
+			}
+		}
+		for _, t := range rule.Transitions {
+			r.Transitions = append(r.Transitions, LifecycleTransition{Days: t.Days, StorageClass: string(t.StorageClass)})
+		}
+		if rule.AbortMultipartUpload != nil {
+			r.AbortMultipartUploadDays = rule.AbortMultipartUpload.Days
+		}
+		if rule.NonVersionExpiration != nil {
+			r.NoncurrentVersionExpirationDays = rule.NonVersionExpiration.NoncurrentDays
+		}
+		for _, t := range rule.NonVersionTransitions {
+			r.NoncurrentVersionTransitions = append(r.NoncurrentVersionTransitions, LifecycleTransition{Days: t.NoncurrentDays, StorageClass: string(t.StorageClass)})
+		}
+		config.Rules = append(config.Rules, r)
+	}
+
+	return config, nil
+}
+
+// PutLifecycle 设置Bucket的生命周期规则，会覆盖已有规则
+func (c *OSSClient) PutLifecycle(name string, config *LifecycleConfig) error {
+	if len(config.Rules) == 0 {
+		if err := c.client.DeleteBucketLifecycle(name); err != nil {
+			return fmt.Errorf("清除生命周期规则失败: %v", err)
+		}
+		return nil
+	}
+
+	var rules []oss.LifecycleRule
+	for _, r := range config.Rules {
+		rule := oss.LifecycleRule{
+			ID:     r.ID,
+			Prefix: r.Prefix,
+			Status: "Disabled",
+		}
+		if r.Enabled {
+			rule.Status = "Enabled"
+		}
+		for key, value := range r.Tags {
+			rule.Tags = append(rule.Tags, oss.Tag{Key: key, Value: value})
+		}
+		if r.ExpirationDays > 0 || r.ExpirationCreatedBeforeDate != "" || r.ExpiredObjectDeleteMarker {
+			rule.Expiration = &oss.LifecycleExpiration{
+				Days:              r.ExpirationDays,
+				CreatedBeforeDate: r.ExpirationCreatedBeforeDate,
+			}
+			if r.ExpiredObjectDeleteMarker {
+				deleteMarker := true
+				rule.Expiration.ExpiredObjectDeleteMarker = &deleteMarker
+			}
+		}
+		for _, t := range r.Transitions {
+			rule.Transitions = append(rule.Transitions, oss.LifecycleTransition{Days: t.Days, StorageClass: oss.StorageClassType(t.StorageClass)})
+		}
+		if r.AbortMultipartUploadDays > 0 {
+			rule.AbortMultipartUpload = &oss.LifecycleAbortMultipartUpload{Days: r.AbortMultipartUploadDays}
+		}
+		if r.NoncurrentVersionExpirationDays > 0 {
+			rule.NonVersionExpiration = &oss.LifecycleVersionExpiration{NoncurrentDays: r.NoncurrentVersionExpirationDays}
+		}
+		for _, t := range r.NoncurrentVersionTransitions {
+			rule.NonVersionTransitions = append(rule.NonVersionTransitions, oss.LifecycleVersionTransition{NoncurrentDays: t.Days, StorageClass: oss.StorageClassType(t.StorageClass)})
+		}
+		rules = append(rules, rule)
+	}
+
+	if err := c.client.SetBucketLifecycle(name, rules); err != nil {
+		return fmt.Errorf("设置生命周期规则失败: %v", err)
+	}
+
+	return nil
+}
+
+// GetCORS 获取Bucket的跨域规则
+func (c *OSSClient) GetCORS(name string) (*CORSConfig, error) {
+	res, err := c.client.GetBucketCORS(name)
+	if isConfigNotFound(err) {
+		return &CORSConfig{Rules: []CORSRuleConfig{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取跨域规则失败: %v", err)
+	}
+
+	config := &CORSConfig{Rules: []CORSRuleConfig{}}
+	for _, rule := range res.CORSRules {
+		config.Rules = append(config.Rules, CORSRuleConfig{
+			AllowedOrigins: rule.AllowedOrigin,
+			AllowedMethods: rule.AllowedMethod,
+			AllowedHeaders: rule.AllowedHeader,
+			ExposeHeaders:  rule.ExposeHeader,
+			MaxAgeSeconds:  rule.MaxAgeSeconds,
+		})
+	}
+
+	return config, nil
+}
+
+// PutCORS 设置Bucket的跨域规则，会覆盖已有规则
+func (c *OSSClient) PutCORS(name string, config *CORSConfig) error {
+	if len(config.Rules) == 0 {
+		if err := c.client.DeleteBucketCORS(name); err != nil {
+			return fmt.Errorf("清除跨域规则失败: %v", err)
+		}
+		return nil
+	}
+
+	var rules []oss.CORSRule
+	for _, r := range config.Rules {
+		rules = append(rules, oss.CORSRule{
+			AllowedOrigin: r.AllowedOrigins,
+			AllowedMethod: r.AllowedMethods,
+			AllowedHeader: r.AllowedHeaders,
+			ExposeHeader:  r.ExposeHeaders,
+			MaxAgeSeconds: r.MaxAgeSeconds,
+		})
+	}
+
+	if err := c.client.SetBucketCORS(name, rules); err != nil {
+		return fmt.Errorf("设置跨域规则失败: %v", err)
+	}
+
+	return nil
+}
+
+// GetReferer 获取Bucket的防盗链白名单
+func (c *OSSClient) GetReferer(name string) (*RefererConfig, error) {
+	res, err := c.client.GetBucketReferer(name)
+	if err != nil {
+		return nil, fmt.Errorf("获取防盗链配置失败: %v", err)
+	}
+
+	config := &RefererConfig{
+		AllowEmptyReferer: res.AllowEmptyReferer,
+		Referers:          res.RefererList,
+	}
+	if config.Referers == nil {
+		config.Referers = []string{}
+	}
+
+	return config, nil
+}
+
+// PutReferer 设置Bucket的防盗链白名单
+func (c *OSSClient) PutReferer(name string, config *RefererConfig) error {
+	if err := c.client.SetBucketReferer(name, config.Referers, config.AllowEmptyReferer); err != nil {
+		return fmt.Errorf("设置防盗链配置失败: %v", err)
+	}
+
+	return nil
+}
+
+// GetWebsite 获取Bucket的静态网站托管配置
+func (c *OSSClient) GetWebsite(name string) (*WebsiteConfig, error) {
+	res, err := c.client.GetBucketWebsite(name)
+	if isConfigNotFound(err) {
+		return &WebsiteConfig{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取静态网站配置失败: %v", err)
+	}
+
+	return &WebsiteConfig{
+		IndexDocument: res.IndexDocument.Suffix,
+		ErrorDocument: res.ErrorDocument.Key,
+	}, nil
+}
+
+// PutWebsite 设置Bucket的静态网站托管配置，索引页为空时关闭静态网站托管
+func (c *OSSClient) PutWebsite(name string, config *WebsiteConfig) error {
+	if config.IndexDocument == "" {
+		if err := c.client.DeleteBucketWebsite(name); err != nil {
+			return fmt.Errorf("关闭静态网站托管失败: %v", err)
+		}
+		return nil
+	}
+
+	if err := c.client.SetBucketWebsite(name, config.IndexDocument, config.ErrorDocument); err != nil {
+		return fmt.Errorf("设置静态网站配置失败: %v", err)
+	}
+
+	return nil
+}
+
+// writeJSONConfig 将配置以JSON格式写入文件，文件路径为空时输出到标准输出
+func writeJSONConfig(path string, config interface{}) error {
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化配置失败: %v", err)
+	}
+	data = append(data, '\n')
+
+	if path == "" || path == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("写入文件失败: %v", err)
+	}
+	fmt.Printf("配置已保存到: %s\n", path)
+	return nil
+}
+
+// readJSONConfig 从JSON文件读取配置
+func readJSONConfig(path string, config interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取文件失败: %v", err)
+	}
+
+	if err := json.Unmarshal(data, config); err != nil {
+		return fmt.Errorf("解析配置文件失败: %v", err)
+	}
+
+	return nil
+}
+
+// bucketCommand 处理 alioss bucket 子命令
+func bucketCommand(client *OSSClient, p *parsedArgs) error {
+	// 默认使用配置文件中的Bucket
+	bucketName := p.str("bucket", client.config.Bucket)
+	createOptions := &BucketCreateOptions{
+		ACL:            p.str("acl", ""),
+		StorageClass:   p.str("storage-class", ""),
+		RedundancyType: p.str("redundancy", ""),
+	}
+	action := p.arg(0)
+	positional := p.args[1:]
+
+	switch action {
+	case "list":
+		buckets, err := client.ListBuckets()
+		if err != nil {
+			return err
+		}
+		if len(buckets) == 0 {
+			fmt.Println("未找到Bucket")
+			return nil
+		}
+		fmt.Println("Bucket列表:")
+		for _, b := range buckets {
+			fmt.Printf("  %-40s %-20s %-12s %s\n", b.Name, b.Location, b.StorageClass,
+				b.CreationDate.Local().Format("2006-01-02 15:04:05"))
+		}
+
+	case "create":
+		if len(positional) < 1 {
+			return fmt.Errorf("请提供Bucket名称")
+		}
+		if err := client.CreateBucket(positional[0], createOptions); err != nil {
+			return err
+		}
+		fmt.Printf("Bucket创建成功: %s\n", positional[0])
+
+	case "info":
+		if len(positional) > 0 {
+			bucketName = positional[0]
+		}
+		info, err := client.GetBucketInfo(bucketName)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("名称:       %s\n", info.Name)
+		fmt.Printf("地域:       %s\n", info.Location)
+		fmt.Printf("创建时间:   %s\n", info.CreationDate.Local().Format("2006-01-02 15:04:05"))
+		fmt.Printf("存储类型:   %s\n", info.StorageClass)
+		fmt.Printf("冗余类型:   %s\n", info.RedundancyType)
+		fmt.Printf("访问权限:   %s\n", info.ACL)
+		fmt.Printf("版本控制:   %s\n", info.Versioning)
+		fmt.Printf("外网域名:   %s\n", info.ExtranetEndpoint)
+		fmt.Printf("内网域名:   %s\n", info.IntranetEndpoint)
+
+	case "delete":
+		// 删除Bucket必须显式指定名称，避免误删配置文件中的Bucket
+		if len(positional) < 1 {
+			return fmt.Errorf("请提供要删除的Bucket名称")
+		}
+		if err := client.DeleteBucket(positional[0]); err != nil {
+			return err
+		}
+		fmt.Printf("Bucket删除成功: %s\n", positional[0])
+
+	case "lifecycle", "cors", "referer", "website":
+		if len(positional) < 1 {
+			return fmt.Errorf("请指定操作: get [文件] 或 put <文件>")
+		}
+		action := positional[0]
+		path := ""
+		if len(positional) > 1 {
+			path = positional[1]
+		}
+		switch action {
+		case "get":
+			return getBucketConfig(client, action, bucketName, path)
+		case "put":
+			if path == "" {
+				return fmt.Errorf("请提供JSON配置文件路径")
+			}
+			if err := putBucketConfig(client, action, bucketName, path); err != nil {
+				return err
+			}
+			fmt.Printf("已更新 %s 的 %s 配置\n", bucketName, action)
+		default:
+			return fmt.Errorf("未知操作: %s", action)
+		}
+
+	default:
+		return fmt.Errorf("未知bucket子命令: %s", action)
+	}
+
+	return nil
+}
+
+// getBucketConfig 读取Bucket配置并保存为JSON
+func getBucketConfig(client *OSSClient, kind, bucketName, path string) error {
+	var config interface{}
+	var err error
+
+	switch kind {
+	case "lifecycle":
+		config, err = client.GetLifecycle(bucketName)
+	case "cors":
+		config, err = client.GetCORS(bucketName)
+	case "referer":
+		config, err = client.GetReferer(bucketName)
+	case "website":
+		config, err = client.GetWebsite(bucketName)
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeJSONConfig(path, config)
+}
+
+// putBucketConfig 从JSON文件读取配置并应用到Bucket
+func putBucketConfig(client *OSSClient, kind, bucketName, path string) error {
+	err := applyBucketConfig(client, kind, bucketName, path)
+	client.audit(AuditEntry{Op: AuditBucketConfig, Bucket: bucketName, Detail: kind}, err)
+	return err
+}
+
+// applyBucketConfig 按配置类型读取JSON文件并调用对应的设置方法
+func applyBucketConfig(client *OSSClient, kind, bucketName, path string) error {
+	switch kind {
+	case "lifecycle":
+		config := &LifecycleConfig{}
+		if err := readJSONConfig(path, config); err != nil {
+			return err
+		}
+		return client.PutLifecycle(bucketName, config)
+	case "cors":
+		config := &CORSConfig{}
+		if err := readJSONConfig(path, config); err != nil {
+			return err
+		}
+		return client.PutCORS(bucketName, config)
+	case "referer":
+		config := &RefererConfig{}
+		if err := readJSONConfig(path, config); err != nil {
+			return err
+		}
+		return client.PutReferer(bucketName, config)
+	case "website":
+		config := &WebsiteConfig{}
+		if err := readJSONConfig(path, config); err != nil {
+			return err
+		}
+		return client.PutWebsite(bucketName, config)
+	}
+
+	return fmt.Errorf("未知配置类型: %s", kind)
+}
+
+// isConfigNotFound 判断错误是否表示Bucket未设置该项配置
+func isConfigNotFound(err error) bool {
+	var serviceErr oss.ServiceError
+	if err == nil || !errors.As(err, &serviceErr) {
+		return false
+	}
+	switch serviceErr.Code {
+	case "NoSuchLifecycle", "NoSuchCORSConfiguration", "NoSuchWebsiteConfiguration":
+		return true
+	}
+	return false
+}
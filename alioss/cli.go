@@ -0,0 +1,562 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 选项参数的类型，解析时检查
+const (
+	valueString   = ""         // 任意字符串
+	valuePositive = "positive" // 正整数
+	valueCount    = "count"    // 非负整数
+	valueDuration = "duration" // Go的时间长度，如 2s、10m
+	valueChoice   = "choice"   // complete中列出的可选值之一
+)
+
+// 参数的补全方式，其它取值为以|分隔的可选值
+const (
+	argNone    = ""
+	argRemote  = "remote"  // OSS路径
+	argLocal   = "local"   // 本地路径
+	argProfile = "profile" // 配置文件中的命名配置
+	argCommand = "command" // 子命令名
+)
+
+// flagSpec 命令选项的定义
+type flagSpec struct {
+	name     string // 长选项名，不带 --
+	short    string // 短选项名，不带 -，可以为空
+	arg      string // 参数的说明，为空时是开关选项
+	kind     string // 参数的类型
+	complete string // 参数的补全方式
+	repeat   bool   // 可以多次指定，值按顺序保存
+	usage    string
+}
+
+// commandSpec 子命令的定义
+type commandSpec struct {
+	name     string
+	usage    string   // 参数部分的用法，如 <本地路径> [OSS路径]
+	summary  string   // 一句话说明
+	minArgs  int      // 最少的位置参数个数
+	maxArgs  int      // 最多的位置参数个数，-1表示不限
+	actions  []string // 第一个位置参数的可选值，如 trash list|restore|empty
+	args     []string // 各个位置参数的补全方式，超出时使用最后一个
+	flags    []flagSpec
+	noClient bool // 不需要连接OSS
+}
+
+// parsedArgs 解析后的命令行
+type parsedArgs struct {
+	spec   *commandSpec
+	args   []string
+	values map[string][]string
+	help   bool
+}
+
+// 常用的选项
+var (
+	workersFlag      = flagSpec{name: "workers", arg: "数量", kind: valuePositive, usage: "并发的工作协程数，默认10"}
+	retriesFlag      = flagSpec{name: "retries", arg: "N", kind: valueCount, usage: "单个文件失败时的重试次数，默认2，0表示不重试"}
+	excludeFlag      = flagSpec{name: "exclude", arg: "模式1,模式2,...", usage: "排除匹配的文件"}
+	symlinksFlag     = flagSpec{name: "symlinks", arg: "follow|skip|store", kind: valueChoice, complete: "follow|skip|store", usage: "符号链接的处理方式，默认follow"}
+	ifExistsFlag     = flagSpec{name: "if-exists", arg: "skip|overwrite|rename|fail", kind: valueChoice, complete: "skip|overwrite|rename|fail", usage: "目标文件已存在时的处理方式"}
+	listParallelFlag = flagSpec{name: "list-parallel", arg: "N", kind: valuePositive, usage: "按子目录并行列举的协程数"}
+	tagFilterFlag    = flagSpec{name: "tag", arg: "键=值", repeat: true, usage: "只处理标签满足条件的文件，可以多次指定"}
+	versionIDFlag    = flagSpec{name: "version-id", arg: "版本ID", usage: "指定文件版本"}
+)
+
+// filterFlags 按时间和大小筛选的选项
+var filterFlags = []flagSpec{
+	{name: "newer-than", arg: "时间", usage: "只处理在该时间之后修改的文件，如 24h、7d、2024-03-01"},
+	{name: "older-than", arg: "时间", usage: "只处理在该时间之前修改的文件"},
+	{name: "min-size", arg: "大小", usage: "只处理不小于该大小的文件，如 512K、1M、2G"},
+	{name: "max-size", arg: "大小", usage: "只处理不大于该大小的文件"},
+}
+
+// globalFlags 所有命令都可以使用的选项，可以放在命令前后的任意位置
+var globalFlags = []flagSpec{
+	{name: "config", short: "f", arg: "配置文件路径", complete: argLocal, usage: "指定配置文件路径，默认为~/.oss-config"},
+	{name: "profile", short: "p", arg: "配置名称", complete: argProfile, usage: "使用配置文件中的命名配置，也可以用环境变量ALIOSS_PROFILE指定"},
+	{name: "on-complete", arg: "命令", usage: "命令完成后运行，标准输入为JSON格式的结果汇总"},
+	{name: "webhook", arg: "URL", usage: "命令完成后POST结果汇总，失败时重试"},
+	{name: "no-hooks", usage: "不运行配置文件中的完成钩子"},
+	{name: "help", short: "h", usage: "显示帮助"},
+}
+
+// withFlags 合并多组选项
+func withFlags(groups ...[]flagSpec) []flagSpec {
+	var flags []flagSpec
+	for _, group := range groups {
+		flags = append(flags, group...)
+	}
+	return flags
+}
+
+// commands 所有子命令，帮助中按此顺序列出
+var commands = []*commandSpec{
+	{
+		name: "upload", usage: "<本地文件或文件夹路径> [OSS路径]", summary: "上传文件或文件夹，本地路径为-时从标准输入上传",
+		minArgs: 1, maxArgs: 2, args: []string{argLocal, argRemote},
+		flags: withFlags([]flagSpec{
+			excludeFlag,
+			{name: "incremental", usage: "只上传有变化的文件"},
+			{name: "concurrent", usage: "并发上传"},
+			workersFlag, retriesFlag, symlinksFlag,
+			{name: "tag", arg: "键=值,...", repeat: true, usage: "为上传的文件设置标签"},
+			ifExistsFlag,
+			{name: "archive", arg: "tar.gz|zip", kind: valueChoice, complete: "tar.gz|zip", usage: "打包压缩后上传为一个文件"},
+			{name: "template", arg: "模板", usage: "按路径模板上传，如 releases/{date}/{basename}"},
+			{name: "cas", usage: "内容寻址上传，相当于 --template " + casTemplate},
+			{name: "json", usage: "按模板上传时以JSON格式输出对应关系"},
+			{name: "sign", arg: "秒数", kind: valuePositive, usage: "按模板上传时输出签名URL"},
+			{name: "part-size", arg: "MB", kind: valuePositive, usage: "从标准输入上传时的分片大小，默认8"},
+			{name: "manifest", arg: "清单文件", complete: argLocal, usage: "把上传文件的SHA256和OSS路径写入SHA256SUMS格式的清单"},
+			{name: "upload-manifest", arg: "OSS路径", complete: argRemote, usage: "上传成功后把清单也上传到OSS，以/结尾时使用清单的文件名"},
+		}, filterFlags),
+	},
+	{
+		name: "download", usage: "<OSS路径> <本地保存路径>", summary: "下载文件或文件夹",
+		minArgs: 2, maxArgs: 2, args: []string{argRemote, argLocal},
+		flags: withFlags([]flagSpec{
+			{name: "concurrent", usage: "并发下载"},
+			workersFlag, retriesFlag, listParallelFlag, versionIDFlag,
+			{name: "extract", usage: "下载压缩包并解压到本地目录"},
+			ifExistsFlag, tagFilterFlag,
+		}, filterFlags),
+	},
+	{
+		name: "cat", usage: "<OSS路径>", summary: "输出文件内容",
+		minArgs: 1, maxArgs: 1, args: []string{argRemote},
+		flags: []flagSpec{{name: "range", arg: "start-end", usage: "只读取部分内容，格式为 start-end、start- 或 -末尾字节数"}},
+	},
+	{
+		name: "list", usage: "[前缀]", summary: "列出文件",
+		minArgs: 0, maxArgs: 1, args: []string{argRemote},
+		flags: withFlags([]flagSpec{
+			{name: "versions", usage: "列出所有历史版本和删除标记"},
+			tagFilterFlag, listParallelFlag, workersFlag,
+		}, filterFlags),
+	},
+	{
+		name: "delete", usage: "<OSS路径或前缀>", summary: "删除文件或文件夹",
+		minArgs: 1, maxArgs: 1, args: []string{argRemote},
+		flags: withFlags([]flagSpec{
+			versionIDFlag, tagFilterFlag,
+			{name: "trash", usage: "移动到回收站"},
+			{name: "permanent", usage: "直接删除，不使用回收站"},
+			listParallelFlag,
+		}, filterFlags),
+	},
+	{
+		name: "undelete", usage: "<OSS路径或前缀/>", summary: "恢复被删除的文件",
+		minArgs: 1, maxArgs: 1, args: []string{argRemote},
+	},
+	{
+		name: "url", usage: "<OSS路径> [过期时间(秒)]", summary: "获取临时URL，默认1小时有效",
+		minArgs: 1, maxArgs: 2, args: []string{argRemote, argNone},
+	},
+	{
+		name: "watch", usage: "<本地目录> [OSS路径]", summary: "监听目录并持续上传",
+		minArgs: 1, maxArgs: 2, args: []string{argLocal, argRemote},
+		flags: []flagSpec{
+			excludeFlag,
+			{name: "incremental", usage: "只上传有变化的文件"},
+			{name: "delete", usage: "本地删除文件后同步删除OSS上的文件"},
+			workersFlag, retriesFlag, symlinksFlag,
+			{name: "debounce", arg: "时间", kind: valueDuration, usage: "文件最后一次变化后等待多久再上传，默认2s"},
+			{name: "rescan", arg: "时间", kind: valueDuration, usage: "定期全量扫描的间隔，默认10m，0表示关闭"},
+		},
+	},
+	{
+		name: "diff", usage: "<本地目录> <OSS路径>", summary: "比较本地目录和OSS",
+		minArgs: 2, maxArgs: 2, args: []string{argLocal, argRemote},
+		flags: []flagSpec{
+			excludeFlag, workersFlag, symlinksFlag,
+			{name: "strict", usage: "对所有文件使用CRC64校验，不信任ETag"},
+			{name: "download", usage: "重新下载文件内容计算CRC64"},
+		},
+	},
+	{
+		name: "verify", usage: "<本地目录> <OSS路径>", summary: "校验本地目录和OSS一致",
+		minArgs: 2, maxArgs: 2, args: []string{argLocal, argRemote},
+		flags: []flagSpec{
+			excludeFlag, workersFlag, symlinksFlag,
+			{name: "strict", usage: "对所有文件使用CRC64校验，不信任ETag（默认开启）"},
+			{name: "download", usage: "重新下载文件内容计算CRC64"},
+		},
+	},
+	{
+		name: "deploy", usage: "<本地目录> <站点前缀>|list <站点前缀>|rollback <站点前缀> [版本]", summary: "部署静态站点，支持回滚",
+		minArgs: 2, maxArgs: 3, args: []string{argLocal, argRemote, argNone},
+		flags: []flagSpec{
+			{name: "version", arg: "名称", usage: "新版本的名称，默认为当前时间，如 20240301-150405"},
+			{name: "keep", arg: "N", kind: valuePositive, usage: "部署后只保留最近部署的N个版本，至少为2"},
+			excludeFlag, workersFlag, retriesFlag,
+		},
+	},
+	{
+		name: "verify-manifest", usage: "<清单文件>", summary: "按SHA256清单校验OSS上的文件",
+		minArgs: 1, maxArgs: 1, args: []string{argLocal},
+		flags: []flagSpec{
+			workersFlag,
+			{name: "prefix", arg: "前缀/", complete: argRemote, usage: "加在清单中路径前的OSS前缀"},
+			{name: "download", usage: "没有SHA256元数据的文件下载内容校验"},
+		},
+	},
+	{
+		name: "replicate", summary: "在Bucket之间复制",
+		flags: []flagSpec{
+			{name: "from", arg: "[配置名:]oss://bucket/前缀", usage: "复制的来源"},
+			{name: "to", arg: "[配置名:]oss://bucket/前缀", usage: "复制的目标"},
+			workersFlag, retriesFlag,
+			{name: "stream", usage: "通过本机中转，用于跨账号或跨地域复制"},
+			{name: "dry-run", usage: "只输出要复制的文件"},
+			{name: "no-verify", usage: "复制后不校验"},
+			{name: "journal", arg: "文件", complete: argLocal, usage: "进度文件，中断后从该文件继续"},
+		},
+	},
+	{
+		name: "history", summary: "查询操作记录",
+		flags: []flagSpec{
+			{name: "command", arg: "命令", complete: argCommand, usage: "只显示该命令的记录"},
+			{name: "prefix", arg: "前缀", complete: argRemote, usage: "只显示涉及该前缀的记录"},
+			{name: "since", arg: "时间", usage: "开始时间，如 24h、7d、2024-03-01"},
+			{name: "until", arg: "时间", usage: "结束时间"},
+			{name: "user", arg: "用户", usage: "只显示该用户的记录"},
+			{name: "errors", usage: "只显示失败的操作"},
+			{name: "limit", arg: "N", kind: valuePositive, usage: "最多显示的条数"},
+			{name: "json", usage: "以JSON格式输出"},
+		},
+	},
+	{
+		name: "trash", usage: "list|restore <批次ID>|empty", summary: "回收站",
+		minArgs: 1, maxArgs: 2, actions: []string{"list", "restore", "empty"},
+		flags: []flagSpec{
+			{name: "force", usage: "恢复时覆盖已存在的文件"},
+			{name: "older-than", arg: "时间", usage: "清空时只删除早于该时间的批次，如 7d"},
+		},
+	},
+	{
+		name: "prune", usage: "[前缀]", summary: "按保留规则清理旧文件",
+		minArgs: 0, maxArgs: 1, args: []string{argRemote},
+		flags: []flagSpec{
+			{name: "older-than", arg: "时间", usage: "只删除早于该时间的文件，如 30d"},
+			{name: "keep-last", arg: "N", kind: valuePositive, usage: "每组保留最新的N个文件"},
+			{name: "group-by", arg: "正则", usage: "分组使用的正则表达式"},
+			{name: "include", arg: "模式,...", usage: "只处理匹配的文件"},
+			{name: "exclude", arg: "模式,...", usage: "不处理匹配的文件"},
+			{name: "rules", arg: "规则文件.yaml", complete: argLocal, usage: "从文件读取清理规则"},
+			{name: "apply", usage: "执行删除，默认只预览"},
+		},
+	},
+	{
+		name: "tag", usage: "set|get|rm <OSS路径或前缀/> [键=值 ...]", summary: "对象标签",
+		minArgs: 2, maxArgs: -1, actions: []string{"set", "get", "rm"}, args: []string{argRemote, argNone},
+		flags: []flagSpec{workersFlag},
+	},
+	{
+		name: "shell", summary: "交互模式",
+	},
+	{
+		name: "serve", summary: "启动Web界面",
+		flags: []flagSpec{
+			{name: "listen", arg: "地址", usage: "监听地址，默认127.0.0.1:8080"},
+			{name: "prefix", arg: "前缀/", complete: argRemote, usage: "只允许访问该前缀"},
+			{name: "user", arg: "用户名", usage: "访问的用户名"},
+			{name: "password", arg: "密码", usage: "访问的密码，也可以用环境变量ALIOSS_PASSWORD指定"},
+			{name: "read-only", usage: "只读模式"},
+			{name: "expire", arg: "秒数", kind: valuePositive, usage: "签名链接有效期，默认3600"},
+		},
+	},
+	{
+		name: "bucket", usage: "list|create <名称>|info [名称]|delete <名称>|lifecycle|cors|referer|website get [JSON文件]|put <JSON文件>", summary: "Bucket管理和配置",
+		minArgs: 1, maxArgs: 3, actions: []string{"list", "create", "info", "delete", "lifecycle", "cors", "referer", "website"},
+		flags: []flagSpec{
+			{name: "bucket", arg: "名称", usage: "操作的Bucket，默认使用配置文件中的Bucket"},
+			{name: "acl", arg: "权限", complete: "private|public-read|public-read-write", usage: "创建时的访问权限"},
+			{name: "storage-class", arg: "存储类型", complete: "Standard|IA|Archive|ColdArchive", usage: "创建时的存储类型"},
+			{name: "redundancy", arg: "LRS|ZRS", kind: valueChoice, complete: "LRS|ZRS", usage: "创建时的冗余类型"},
+		},
+	},
+	{
+		name: "gui", summary: "启动桌面图形界面，需要使用 go build -tags gui 编译", noClient: true,
+		flags: []flagSpec{
+			{name: "demo", usage: "使用内存中的示例数据，不连接OSS"},
+		},
+	},
+	{
+		name: "completion", usage: "bash|zsh|fish", summary: "输出命令补全脚本",
+		minArgs: 1, maxArgs: 1, actions: []string{"bash", "zsh", "fish"}, noClient: true,
+	},
+	{
+		name: "help", usage: "[命令]", summary: "显示命令的帮助",
+		minArgs: 0, maxArgs: 1, args: []string{argCommand}, noClient: true,
+	},
+}
+
+// findCommand 按名称查找子命令
+func findCommand(name string) *commandSpec {
+	for _, spec := range commands {
+		if spec.name == name {
+			return spec
+		}
+	}
+	return nil
+}
+
+// findFlag 按名称查找选项，name带有 - 或 --，先查找全局选项
+func findFlag(spec *commandSpec, name string) *flagSpec {
+	groups := [][]flagSpec{globalFlags}
+	if spec != nil {
+		groups = append(groups, spec.flags)
+	}
+	for _, group := range groups {
+		for i := range group {
+			flag := &group[i]
+			if name == "--"+flag.name || (flag.short != "" && name == "-"+flag.short) {
+				return flag
+			}
+		}
+	}
+	return nil
+}
+
+// isFlagArg 判断参数是否为选项，单独的 - 表示标准输入
+func isFlagArg(arg string) bool {
+	return len(arg) > 1 && arg[0] == '-'
+}
+
+// parseCommandLine 解析命令行，选项可以出现在任意位置，-- 之后的参数都作为位置参数
+// 没有指定命令时返回的spec为nil
+func parseCommandLine(args []string) (*parsedArgs, error) {
+	p := &parsedArgs{values: make(map[string][]string)}
+	onlyArgs := false
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !onlyArgs && arg == "--" {
+			onlyArgs = true
+			continue
+		}
+
+		if !onlyArgs && isFlagArg(arg) {
+			name, value, hasValue := strings.Cut(arg, "=")
+			flag := findFlag(p.spec, name)
+			if flag == nil {
+				if p.spec == nil {
+					return p, fmt.Errorf("未知选项: %s（命令的选项需要放在命令名之后）", name)
+				}
+				return p, fmt.Errorf("%s 命令没有 %s 选项", p.spec.name, name)
+			}
+
+			if flag.arg == "" {
+				if hasValue {
+					return p, fmt.Errorf("选项 %s 不需要参数", name)
+				}
+				value = "true"
+			} else if !hasValue {
+				if i+1 >= len(args) {
+					return p, fmt.Errorf("选项 %s 需要参数: %s", name, flag.arg)
+				}
+				i++
+				value = args[i]
+			}
+			if err := checkFlagValue(flag, value); err != nil {
+				return p, err
+			}
+
+			if flag.name == "help" {
+				p.help = true
+			} else if flag.repeat {
+				p.values[flag.name] = append(p.values[flag.name], value)
+			} else {
+				p.values[flag.name] = []string{value}
+			}
+			continue
+		}
+
+		if p.spec == nil {
+			p.spec = findCommand(arg)
+			if p.spec == nil {
+				return p, fmt.Errorf("未知命令: %s", arg)
+			}
+			continue
+		}
+		p.args = append(p.args, arg)
+	}
+
+	if p.spec == nil || p.help {
+		return p, nil
+	}
+	if len(p.args) < p.spec.minArgs {
+		return p, fmt.Errorf("缺少参数，用法: alioss %s %s", p.spec.name, p.spec.usage)
+	}
+	if p.spec.maxArgs >= 0 && len(p.args) > p.spec.maxArgs {
+		return p, fmt.Errorf("多余的参数: %s", strings.Join(p.args[p.spec.maxArgs:], " "))
+	}
+	if len(p.spec.actions) > 0 && !containsString(p.spec.actions, p.args[0]) {
+		return p, fmt.Errorf("未知的 %s 操作: %s，可选 %s", p.spec.name, p.args[0], strings.Join(p.spec.actions, "、"))
+	}
+	return p, nil
+}
+
+// checkFlagValue 按选项的类型检查参数
+func checkFlagValue(flag *flagSpec, value string) error {
+	switch flag.kind {
+	case valuePositive:
+		if n, err := strconv.Atoi(value); err != nil || n <= 0 {
+			return fmt.Errorf("--%s 需要正整数: %s", flag.name, value)
+		}
+	case valueCount:
+		if n, err := strconv.Atoi(value); err != nil || n < 0 {
+			return fmt.Errorf("--%s 需要非负整数: %s", flag.name, value)
+		}
+	case valueDuration:
+		if d, err := time.ParseDuration(value); err != nil || d < 0 {
+			return fmt.Errorf("--%s 需要时间长度，如 2s、10m: %s", flag.name, value)
+		}
+	case valueChoice:
+		if !containsString(strings.Split(flag.complete, "|"), value) {
+			return fmt.Errorf("--%s 的取值无效: %s，可选 %s", flag.name, value, strings.ReplaceAll(flag.complete, "|", "、"))
+		}
+	}
+	return nil
+}
+
+// containsString 判断切片中是否包含s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// has 是否指定了选项
+func (p *parsedArgs) has(name string) bool {
+	return len(p.values[name]) > 0
+}
+
+// str 选项的值，未指定时返回def
+func (p *parsedArgs) str(name, def string) string {
+	if values := p.values[name]; len(values) > 0 {
+		return values[len(values)-1]
+	}
+	return def
+}
+
+// list 可以多次指定的选项的所有值
+func (p *parsedArgs) list(name string) []string {
+	return p.values[name]
+}
+
+// int 整数选项的值，解析时已检查过格式
+func (p *parsedArgs) int(name string, def int) int {
+	if !p.has(name) {
+		return def
+	}
+	n, _ := strconv.Atoi(p.str(name, ""))
+	return n
+}
+
+// duration 时间长度选项的值，解析时已检查过格式
+func (p *parsedArgs) duration(name string, def time.Duration) time.Duration {
+	if !p.has(name) {
+		return def
+	}
+	d, _ := time.ParseDuration(p.str(name, ""))
+	return d
+}
+
+// arg 第i个位置参数，不存在时返回空字符串
+func (p *parsedArgs) arg(i int) string {
+	if i < len(p.args) {
+		return p.args[i]
+	}
+	return ""
+}
+
+// printCommandHelp 输出子命令的帮助
+func printCommandHelp(spec *commandSpec) {
+	fmt.Printf("%s\n\n", spec.summary)
+	usage := "alioss " + spec.name
+	if spec.usage != "" {
+		usage += " " + spec.usage
+	}
+	if len(spec.flags) > 0 {
+		usage += " [选项]"
+	}
+	fmt.Printf("用法: %s\n", usage)
+
+	if len(spec.flags) > 0 {
+		fmt.Println("\n选项:")
+		printFlags(spec.flags)
+	}
+	fmt.Println("\n全局选项:")
+	printFlags(globalFlags)
+}
+
+// printFlags 输出选项列表，说明按最长的选项对齐
+func printFlags(flags []flagSpec) {
+	names := make([]string, len(flags))
+	width := 0
+	for i, flag := range flags {
+		names[i] = "--" + flag.name
+		if flag.short != "" {
+			names[i] = "-" + flag.short + ", " + names[i]
+		}
+		if flag.arg != "" {
+			names[i] += " <" + flag.arg + ">"
+		}
+		if w := displayWidth(names[i]); w > width {
+			width = w
+		}
+	}
+	for i, flag := range flags {
+		fmt.Printf("  %s%s  %s\n", names[i], strings.Repeat(" ", width-displayWidth(names[i])), flag.usage)
+	}
+}
+
+// displayWidth 终端中的显示宽度，中文等宽字符占两列
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		if isWideRune(r) {
+			width += 2
+		} else {
+			width++
+		}
+	}
+	return width
+}
+
+// isWideRune 判断是否为占两列的宽字符
+func isWideRune(r rune) bool {
+	return (r >= 0x1100 && r <= 0x115f) ||
+		(r >= 0x2e80 && r <= 0xa4cf) ||
+		(r >= 0xac00 && r <= 0xd7a3) ||
+		(r >= 0xf900 && r <= 0xfaff) ||
+		(r >= 0xfe30 && r <= 0xfe4f) ||
+		(r >= 0xff00 && r <= 0xff60) ||
+		(r >= 0xffe0 && r <= 0xffe6) ||
+		(r >= 0x1f300 && r <= 0x1f64f) ||
+		(r >= 0x1f900 && r <= 0x1f9ff) ||
+		(r >= 0x20000 && r <= 0x3fffd)
+}
+
+// usageError 输出参数错误并以状态2退出
+func usageError(spec *commandSpec, err error) {
+	fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+	if spec != nil {
+		fmt.Fprintf(os.Stderr, "使用 alioss %s --help 查看用法\n", spec.name)
+	} else {
+		fmt.Fprintln(os.Stderr, "使用 alioss --help 查看用法")
+	}
+	exit(2)
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"hash/crc64"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// crc64Table OSS使用的CRC64算法（ECMA-182）
+var crc64Table = crc64.MakeTable(crc64.ECMA)
+
+// CompareOptions 比较本地目录和OSS前缀的选项
+type CompareOptions struct {
+	UploadOptions      // 扫描本地目录时使用的排除模式和符号链接处理方式
+	Strict        bool // 对所有文件使用CRC64校验，不信任ETag
+	Download      bool // 重新下载文件内容计算CRC64，同时校验OSS上保存的CRC64
+}
+
+// CompareResult 比较结果，路径均为相对于本地目录和OSS前缀的路径
+type CompareResult struct {
+	OnlyLocal  []string // 只存在于本地的文件
+	OnlyRemote []string // 只存在于OSS的文件
+	Different  []string // 内容不同的文件
+	Failed     []string // 比较时出错的文件
+	SameCount  int      // 内容相同的文件数
+}
+
+// HasDifference 是否存在差异
+func (r *CompareResult) HasDifference() bool {
+	return len(r.OnlyLocal) > 0 || len(r.OnlyRemote) > 0 || len(r.Different) > 0
+}
+
+// compareTask 表示一个文件内容比较任务
+type compareTask struct {
+	file   *localFile
+	object oss.ObjectProperties
+	same   bool
+	err    error
+}
+
+// Compare 比较本地目录和OSS前缀下的文件
+// 先比较文件大小，再比较ETag（普通上传的ETag即MD5），分片上传等ETag不是MD5的文件使用CRC64比较
+func (c *OSSClient) Compare(localDirPath, ossDirPath string, options *CompareOptions) (*CompareResult, error) {
+	if options == nil {
+		options = &CompareOptions{}
+	}
+	workerCount := options.WorkerCount
+	if workerCount <= 0 {
+		workerCount = 10
+	}
+
+	// 确保OSS路径以斜杠结尾
+	if ossDirPath != "" && !strings.HasSuffix(ossDirPath, "/") {
+		ossDirPath += "/"
+	}
+
+	// 标准化OSS路径，去除前导斜杠
+	ossDirPath = strings.TrimPrefix(ossDirPath, "/")
+
+	fmt.Printf("列出OSS目录: %s\n", ossDirPath)
+	objects, err := c.ListObjects(ossDirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	remote := make(map[string]oss.ObjectProperties, len(objects))
+	for _, object := range objects {
+		relPath := strings.TrimPrefix(object.Key, ossDirPath)
+		// 跳过目录本身、目录占位对象和被排除的文件
+		if relPath == "" || strings.HasSuffix(relPath, "/") || shouldExclude(relPath, &options.UploadOptions) {
+			continue
+		}
+		remote[relPath] = object
+	}
+
+	fmt.Printf("扫描本地目录: %s\n", localDirPath)
+	result := &CompareResult{}
+	var tasks []*compareTask
+	_, err = walkLocalDir(localDirPath, &options.UploadOptions, func(file *localFile) error {
+		object, ok := remote[file.relPath]
+		if !ok {
+			result.OnlyLocal = append(result.OnlyLocal, file.relPath)
+			return nil
+		}
+		delete(remote, file.relPath)
+		tasks = append(tasks, &compareTask{file: file, object: object})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("扫描目录失败: %v", err)
+	}
+
+	for relPath := range remote {
+		result.OnlyRemote = append(result.OnlyRemote, relPath)
+	}
+
+	fmt.Printf("正在比较 %d 个文件...\n", len(tasks))
+
+	// 失败信息在汇总时统一输出，这里只提示重试
+	scheduler := newTransferScheduler(context.Background(), "比较", workerCount, 0, func(event TransferEvent) {
+		if event.Status == TransferRetry {
+			printTransferEvent("比较", event)
+		}
+	})
+	for _, task := range tasks {
+		scheduler.submit(&transferTask{
+			name: task.file.relPath,
+			size: task.file.info.Size(),
+			run: func(ctx context.Context) (string, error) {
+				task.same, task.err = c.sameContent(task.file, task.object, options)
+				if task.err != nil {
+					return "", task.err
+				}
+				return task.file.relPath, nil
+			},
+		})
+	}
+	scheduler.wait()
+
+	for _, task := range tasks {
+		switch {
+		case task.err != nil:
+			result.Failed = append(result.Failed, task.file.relPath)
+			fmt.Fprintf(os.Stderr, "比较失败: %s - %v\n", task.file.relPath, task.err)
+		case task.same:
+			result.SameCount++
+		default:
+			result.Different = append(result.Different, task.file.relPath)
+		}
+	}
+
+	sort.Strings(result.OnlyLocal)
+	sort.Strings(result.OnlyRemote)
+	sort.Strings(result.Different)
+	sort.Strings(result.Failed)
+
+	return result, nil
+}
+
+// sameContent 比较本地文件和OSS文件的内容是否相同
+func (c *OSSClient) sameContent(file *localFile, object oss.ObjectProperties, options *CompareOptions) (bool, error) {
+	localSize := file.info.Size()
+	if file.linkTarget != "" {
+		localSize = int64(len(file.linkTarget))
+	}
+	if localSize != object.Size {
+		return false, nil
+	}
+
+	// 普通上传的ETag就是内容的MD5，与needUpload的判断方式一致
+	etag := strings.Trim(object.ETag, "\"")
+	if !options.Strict && !options.Download && isMD5ETag(etag) {
+		localMD5, err := localContentMD5(file)
+		if err != nil {
+			return false, fmt.Errorf("计算本地文件MD5失败: %v", err)
+		}
+		return strings.EqualFold(localMD5, etag), nil
+	}
+
+	localCRC, err := localContentCRC64(file)
+	if err != nil {
+		return false, fmt.Errorf("计算本地文件CRC64失败: %v", err)
+	}
+
+	remoteCRC, err := c.remoteCRC64(object.Key, options.Download)
+	if err != nil {
+		return false, err
+	}
+
+	return localCRC == remoteCRC, nil
+}
+
+// remoteCRC64 获取OSS文件的CRC64
+// download为true时下载文件内容重新计算，并与OSS保存的CRC64比对
+func (c *OSSClient) remoteCRC64(ossPath string, download bool) (uint64, error) {
+	if !download {
+		meta, err := c.bucket.GetObjectDetailedMeta(ossPath)
+		if err != nil {
+			return 0, fmt.Errorf("获取远程文件元信息失败: %v", err)
+		}
+		return parseCRC64(meta.Get(oss.HTTPHeaderOssCRC64))
+	}
+
+	result, err := c.bucket.DoGetObject(&oss.GetObjectRequest{ObjectKey: ossPath}, nil)
+	if err != nil {
+		return 0, fmt.Errorf("下载文件失败: %v", err)
+	}
+	defer result.Response.Close()
+
+	hash := crc64.New(crc64Table)
+	if _, err := io.Copy(hash, result.Response.Body); err != nil {
+		return 0, fmt.Errorf("下载文件失败: %v", err)
+	}
+	sum := hash.Sum64()
+
+	if stored := result.Response.Headers.Get(oss.HTTPHeaderOssCRC64); stored != "" {
+		storedCRC, err := parseCRC64(stored)
+		if err != nil {
+			return 0, err
+		}
+		if storedCRC != sum {
+			return 0, fmt.Errorf("下载内容与OSS保存的CRC64不一致")
+		}
+	}
+
+	return sum, nil
+}
+
+// parseCRC64 解析OSS返回的CRC64
+func parseCRC64(value string) (uint64, error) {
+	if value == "" {
+		return 0, fmt.Errorf("远程文件没有CRC64信息")
+	}
+	crc, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("无效的CRC64: %s", value)
+	}
+	return crc, nil
+}
+
+// isMD5ETag 判断ETag是否为内容的MD5（分片上传和追加上传的ETag不是）
+func isMD5ETag(etag string) bool {
+	if len(etag) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(etag)
+	return err == nil
+}
+
+// localContentCRC64 计算本地文件或链接对象内容的CRC64
+func localContentCRC64(file *localFile) (uint64, error) {
+	if file.linkTarget != "" {
+		return crc64.Checksum([]byte(file.linkTarget), crc64Table), nil
+	}
+
+	f, err := os.Open(file.path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	hash := crc64.New(crc64Table)
+	if _, err := io.Copy(hash, f); err != nil {
+		return 0, err
+	}
+
+	return hash.Sum64(), nil
+}
+
+// printCompareResult 输出比较结果
+func printCompareResult(result *CompareResult) {
+	for _, relPath := range result.OnlyLocal {
+		fmt.Printf("+ 仅本地: %s\n", relPath)
+	}
+	for _, relPath := range result.OnlyRemote {
+		fmt.Printf("- 仅远程: %s\n", relPath)
+	}
+	for _, relPath := range result.Different {
+		fmt.Printf("* 内容不同: %s\n", relPath)
+	}
+
+	fmt.Printf("\n比较完成: %d 个文件相同", result.SameCount)
+	if len(result.OnlyLocal) > 0 {
+		fmt.Printf(", %d 个仅本地", len(result.OnlyLocal))
+	}
+	if len(result.OnlyRemote) > 0 {
+		fmt.Printf(", %d 个仅远程", len(result.OnlyRemote))
+	}
+	if len(result.Different) > 0 {
+		fmt.Printf(", %d 个内容不同", len(result.Different))
+	}
+	if len(result.Failed) > 0 {
+		fmt.Printf(", %d 个比较失败", len(result.Failed))
+	}
+	fmt.Println()
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// completeCommandName 补全脚本调用的内部命令，参数为已输入的词，最后一个是正在输入的词
+// 每行输出一个候选，没有候选时补全脚本回退到本地文件补全
+const completeCommandName = "__complete"
+
+// bashCompletion bash补全脚本
+const bashCompletion = `# alioss 的bash补全，使用方法: source <(alioss completion bash)
+_alioss() {
+    local IFS=$'\n'
+    COMPREPLY=($("${COMP_WORDS[0]}" __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+    if [ ${#COMPREPLY[@]} -eq 0 ]; then
+        compopt -o default
+    elif [ ${#COMPREPLY[@]} -eq 1 ] && [[ ${COMPREPLY[0]} == */ ]]; then
+        compopt -o nospace
+    fi
+}
+complete -F _alioss alioss
+`
+
+// zshCompletion zsh补全脚本
+const zshCompletion = `#compdef alioss
+# alioss 的zsh补全，使用方法: source <(alioss completion zsh)
+_alioss() {
+    local -a candidates dirs others
+    candidates=("${(@f)$(${words[1]} __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    candidates=(${candidates:#})
+    if (( ${#candidates} == 0 )); then
+        _files
+        return
+    fi
+    dirs=(${(M)candidates:#*/})
+    others=(${candidates:#*/})
+    (( ${#dirs} )) && compadd -S '' -- "${dirs[@]}"
+    (( ${#others} )) && compadd -- "${others[@]}"
+}
+compdef _alioss alioss
+`
+
+// fishCompletion fish补全脚本
+const fishCompletion = `# alioss 的fish补全，使用方法: alioss completion fish | source
+function __alioss_complete
+    set -l tokens (commandline -opc)
+    set -l current (commandline -ct)
+    set -l candidates ($tokens[1] __complete $tokens[2..-1] $current 2>/dev/null)
+    if test (count $candidates) -eq 0
+        __fish_complete_path $current
+    else
+        printf '%s\n' $candidates
+    end
+end
+complete -c alioss -f -a '(__alioss_complete)'
+`
+
+// printCompletionScript 输出指定shell的补全脚本
+func printCompletionScript(shell string) {
+	scripts := map[string]string{"bash": bashCompletion, "zsh": zshCompletion, "fish": fishCompletion}
+	os.Stdout.WriteString(scripts[shell])
+}
+
+// completeCommandLine 输出正在输入的词的补全候选
+func completeCommandLine(words []string) {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	current := words[len(words)-1]
+
+	var spec *commandSpec
+	var pending *flagSpec
+	options := &ClientOptions{Profile: os.Getenv("ALIOSS_PROFILE")}
+	positional := 0
+	onlyArgs := false
+
+	// 按解析命令行的规则找出命令、位置参数的序号和正在输入参数的选项
+	before := words[:len(words)-1]
+	for i := 0; i < len(before); i++ {
+		word := before[i]
+		if !onlyArgs && word == "--" {
+			onlyArgs = true
+			continue
+		}
+		if !onlyArgs && isFlagArg(word) {
+			name, value, hasValue := strings.Cut(word, "=")
+			flag := findFlag(spec, name)
+			if flag == nil || flag.arg == "" {
+				continue
+			}
+			if !hasValue {
+				if i+1 == len(before) {
+					pending = flag
+					break
+				}
+				i++
+				value = before[i]
+			}
+			switch flag.name {
+			case "config":
+				options.ConfigFile = value
+			case "profile":
+				options.Profile = value
+			}
+			continue
+		}
+		if spec == nil {
+			if spec = findCommand(word); spec == nil {
+				return
+			}
+			continue
+		}
+		positional++
+	}
+
+	var candidates []string
+	switch {
+	case pending != nil:
+		candidates = completeValue(pending.complete, current, options)
+	case !onlyArgs && strings.HasPrefix(current, "-") && strings.Contains(current, "="):
+		// --选项=值 的形式
+		name, value, _ := strings.Cut(current, "=")
+		if flag := findFlag(spec, name); flag != nil && flag.arg != "" {
+			for _, candidate := range completeValue(flag.complete, value, options) {
+				candidates = append(candidates, name+"="+candidate)
+			}
+		}
+	case !onlyArgs && strings.HasPrefix(current, "-"):
+		flags := globalFlags
+		if spec != nil {
+			flags = withFlags(globalFlags, spec.flags)
+		}
+		for _, flag := range flags {
+			candidates = append(candidates, "--"+flag.name)
+		}
+	case spec == nil:
+		candidates = completeValue(argCommand, current, options)
+	case spec.maxArgs >= 0 && positional >= spec.maxArgs:
+	case len(spec.actions) > 0 && positional == 0:
+		candidates = spec.actions
+	case len(spec.args) > 0:
+		index := positional
+		if len(spec.actions) > 0 {
+			index--
+		}
+		if index >= len(spec.args) {
+			index = len(spec.args) - 1
+		}
+		candidates = completeValue(spec.args[index], current, options)
+	}
+
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, current) {
+			fmt.Println(candidate)
+		}
+	}
+}
+
+// completeValue 按补全方式返回候选，本地路径返回nil，由shell补全
+func completeValue(kind, current string, options *ClientOptions) []string {
+	switch kind {
+	case argNone, argLocal:
+		return nil
+	case argCommand:
+		var names []string
+		for _, spec := range commands {
+			names = append(names, spec.name)
+		}
+		return names
+	case argProfile:
+		config, err := loadConfig(&ClientOptions{ConfigFile: options.ConfigFile})
+		if err != nil {
+			return nil
+		}
+		var names []string
+		for name := range config.Profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names
+	case argRemote:
+		return completeRemoteKeys(current, options)
+	}
+	return strings.Split(kind, "|")
+}
+
+// completeRemoteKeys 列出正在输入的路径所在目录的下一层内容
+func completeRemoteKeys(current string, options *ClientOptions) []string {
+	client, err := NewOSSClient(options)
+	if err != nil {
+		return nil
+	}
+
+	// 与命令一样忽略开头的斜杠，候选保留用户输入的形式
+	lead := ""
+	if strings.HasPrefix(current, "/") {
+		lead = "/"
+	}
+	word := strings.TrimPrefix(current, "/")
+	dirPart := ""
+	if i := strings.LastIndex(word, "/"); i >= 0 {
+		dirPart = word[:i+1]
+	}
+
+	dirs, objects, err := client.ListDir(dirPart)
+	if err != nil {
+		return nil
+	}
+	var candidates []string
+	for _, dir := range dirs {
+		candidates = append(candidates, lead+dir)
+	}
+	for _, object := range objects {
+		candidates = append(candidates, lead+object.Key)
+	}
+	sort.Strings(candidates)
+	return candidates
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// 目标文件已存在时的处理策略
+const (
+	IfExistsOverwrite = "overwrite" // 覆盖
+	IfExistsSkip      = "skip"      // 跳过
+	IfExistsRename    = "rename"    // 在文件名后追加序号，如 app-1.tar.gz
+	IfExistsFail      = "fail"      // 报错
+)
+
+// conflictLog 记录一次上传或下载中目标已存在的文件
+type conflictLog struct {
+	mu       sync.Mutex
+	policy   string
+	entries  []string
+	reserved map[string]bool // 本次已使用的目标路径，避免并发重命名时选中同一个名字
+}
+
+// newConflictLog 创建冲突记录，policy为空时保持原有的直接覆盖行为，不做任何检查
+func newConflictLog(policy string) *conflictLog {
+	return &conflictLog{policy: policy, reserved: make(map[string]bool)}
+}
+
+// add 记录一个冲突及其处理结果
+func (l *conflictLog) add(target, result string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, target+" -> "+result)
+}
+
+// reserve 占用目标路径，已被本次传输占用时返回false
+func (l *conflictLog) reserve(target string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.reserved[target] {
+		return false
+	}
+	l.reserved[target] = true
+	return true
+}
+
+// release 释放占用的目标路径，写入失败后重试时可以再次使用
+func (l *conflictLog) release(target string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.reserved, target)
+}
+
+// printSummary 输出冲突汇总
+func (l *conflictLog) printSummary() {
+	if len(l.entries) == 0 {
+		return
+	}
+	fmt.Printf("冲突: %d 个文件已存在 (--if-exists %s)\n", len(l.entries), l.policy)
+	for _, entry := range l.entries {
+		fmt.Printf("  %s\n", entry)
+	}
+}
+
+// conflictName 在文件名后追加序号，扩展名保持不变
+func conflictName(name string, n int) string {
+	ext := fileExt(name)
+	stem := strings.TrimSuffix(name, ext)
+	if stem == "" {
+		// 以点开头的文件，如 .bashrc
+		return name + "-" + strconv.Itoa(n)
+	}
+	return stem + "-" + strconv.Itoa(n) + ext
+}
+
+// isFileAlreadyExists 判断是否为设置了禁止覆盖时OSS返回的文件已存在错误
+func isFileAlreadyExists(err error) bool {
+	var serviceErr oss.ServiceError
+	return errors.As(err, &serviceErr) && (serviceErr.Code == "FileAlreadyExists" || serviceErr.StatusCode == http.StatusConflict)
+}
+
+// isNotFound 判断是否为OSS返回的文件不存在错误
+func isNotFound(err error) bool {
+	var serviceErr oss.ServiceError
+	return errors.As(err, &serviceErr) && serviceErr.StatusCode == http.StatusNotFound
+}
+
+// putLocalFileIfExists 按冲突策略上传文件，返回实际上传的OSS路径，跳过时返回空字符串
+// 除覆盖外都带上禁止覆盖的请求头，检查和上传之间有其它客户端写入时也不会覆盖
+func (c *OSSClient) putLocalFileIfExists(ctx context.Context, file *localFile, ossPath string, options *UploadOptions, conflicts *conflictLog) (string, error) {
+	if conflicts == nil || conflicts.policy == "" {
+		return ossPath, c.putLocalFile(ctx, file, ossPath, options)
+	}
+
+	for n := 0; ; n++ {
+		key := ossPath
+		if n > 0 {
+			key = path.Join(path.Dir(ossPath), conflictName(path.Base(ossPath), n))
+		}
+
+		exist, err := c.bucket.IsObjectExist(key)
+		if err != nil {
+			return "", fmt.Errorf("检查文件是否存在失败: %v", err)
+		}
+
+		if conflicts.policy == IfExistsOverwrite {
+			if exist {
+				conflicts.add(ossPath, "覆盖")
+			}
+			return key, c.putLocalFile(ctx, file, key, options)
+		}
+
+		if !exist && conflicts.reserve(key) {
+			err := c.putLocalFile(ctx, file, key, options, oss.ForbidOverWrite(true))
+			if err == nil {
+				if n > 0 {
+					conflicts.add(ossPath, "重命名为 "+key)
+				}
+				return key, nil
+			}
+			if !isFileAlreadyExists(err) {
+				conflicts.release(key)
+				return "", err
+			}
+			// 检查之后被其它客户端写入
+		}
+
+		switch conflicts.policy {
+		case IfExistsSkip:
+			conflicts.add(ossPath, "跳过")
+			return "", nil
+		case IfExistsFail:
+			conflicts.add(ossPath, "失败")
+			return "", fmt.Errorf("文件已存在: %s", ossPath)
+		}
+	}
+}
+
+// resolveLocalConflict 按冲突策略处理本地已存在的文件，返回实际保存的路径，跳过时返回空字符串
+func resolveLocalConflict(localPath string, conflicts *conflictLog) (string, error) {
+	if conflicts == nil || conflicts.policy == "" {
+		return localPath, nil
+	}
+
+	for n := 0; ; n++ {
+		target := localPath
+		if n > 0 {
+			target = filepath.Join(filepath.Dir(localPath), conflictName(filepath.Base(localPath), n))
+		}
+
+		_, err := os.Lstat(target)
+		if err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+		exist := err == nil
+
+		if conflicts.policy == IfExistsOverwrite {
+			if exist {
+				conflicts.add(localPath, "覆盖")
+			}
+			return target, nil
+		}
+
+		if !exist && conflicts.reserve(target) {
+			if n > 0 {
+				conflicts.add(localPath, "重命名为 "+target)
+			}
+			return target, nil
+		}
+
+		switch conflicts.policy {
+		case IfExistsSkip:
+			conflicts.add(localPath, "跳过")
+			return "", nil
+		case IfExistsFail:
+			conflicts.add(localPath, "失败")
+			return "", fmt.Errorf("本地文件已存在: %s", localPath)
+		}
+	}
+}
@@ -0,0 +1,389 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// 静态站点部署的目录结构: <站点前缀>releases/<版本>/ 保存每个版本的完整文件，
+// <站点前缀>index.html 是指向当前版本的入口，切换版本只需要重写这一个文件
+const (
+	deployReleasesDir   = "releases/"
+	deployIndexName     = "index.html"
+	deployVersionLayout = "20060102-150405"
+
+	// metaDeployVersion 入口文件上记录当前版本的对象元数据名称
+	metaDeployVersion = "Deploy-Version"
+
+	// 每个版本的路径都不同，版本中的资源文件可以永久缓存；HTML需要每次验证
+	assetCacheControl = "public, max-age=31536000, immutable"
+	htmlCacheControl  = "no-cache"
+)
+
+// headTagPattern 匹配HTML中的<head>标签，用于插入<base>
+var headTagPattern = regexp.MustCompile(`(?i)<head(\s[^>]*)?>`)
+
+// DeployOptions 部署选项
+type DeployOptions struct {
+	Version         string   // 版本名称，为空时使用当前时间
+	Keep            int      // 保留最近的版本数，0表示全部保留，否则至少为2
+	ExcludePatterns []string // 排除的文件或目录模式
+	WorkerCount     int      // 并发上传的工作协程数
+	Retries         int      // 失败重试次数，0使用默认值，负数表示不重试
+}
+
+// siteDir 标准化站点前缀，去除前导斜杠并以斜杠结尾，为空时表示Bucket根目录
+func siteDir(site string) string {
+	site = strings.Trim(site, "/")
+	if site == "" {
+		return ""
+	}
+	return site + "/"
+}
+
+// Deploy 把本地目录部署为站点的新版本
+// 先上传资源文件，再上传各级index.html，全部成功后才切换入口文件，访问者不会看到上传了一半的版本
+func (c *OSSClient) Deploy(ctx context.Context, localDir, site string, options *DeployOptions) error {
+	if options == nil {
+		options = &DeployOptions{}
+	}
+	if options.Keep < 0 || options.Keep == 1 {
+		return fmt.Errorf("--keep 至少为2，需要保留上一个版本用于回滚")
+	}
+	site = siteDir(site)
+
+	if _, err := os.Stat(filepath.Join(localDir, deployIndexName)); err != nil {
+		return fmt.Errorf("目录中没有%s: %s", deployIndexName, localDir)
+	}
+
+	version := options.Version
+	if version == "" {
+		version = time.Now().Format(deployVersionLayout)
+	}
+	if strings.ContainsAny(version, "/\\") || strings.HasPrefix(version, ".") {
+		return fmt.Errorf("无效的版本名称: %s", version)
+	}
+
+	// 版本一旦发布就不再修改，回滚时才能得到原来的内容
+	releaseDir := site + deployReleasesDir + version + "/"
+	dirs, objects, err := c.ListDir(releaseDir)
+	if err != nil {
+		return err
+	}
+	if len(dirs) > 0 || len(objects) > 0 {
+		return fmt.Errorf("版本已存在: %s", version)
+	}
+
+	var assets, pages []*localFile
+	uploadOptions := &UploadOptions{ExcludePatterns: options.ExcludePatterns}
+	if _, err := walkLocalDir(localDir, uploadOptions, func(file *localFile) error {
+		if path.Base(file.relPath) == deployIndexName {
+			pages = append(pages, file)
+		} else {
+			assets = append(assets, file)
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("扫描目录失败: %v", err)
+	}
+
+	fmt.Printf("部署版本 %s 到 %s\n", version, releaseDir)
+	for _, files := range [][]*localFile{assets, pages} {
+		if err := c.uploadSiteFiles(ctx, files, releaseDir, options); err != nil {
+			return fmt.Errorf("%v，站点未切换到新版本", err)
+		}
+	}
+
+	if err := c.switchSite(ctx, site, version); err != nil {
+		return err
+	}
+	fmt.Printf("站点 /%s 已切换到版本 %s\n", site, version)
+
+	if options.Keep > 0 {
+		return c.pruneSiteVersions(site, version, options.Keep)
+	}
+	return nil
+}
+
+// uploadSiteFiles 通过调度器上传一组站点文件
+func (c *OSSClient) uploadSiteFiles(ctx context.Context, files []*localFile, releaseDir string, options *DeployOptions) error {
+	if len(files) == 0 {
+		return nil
+	}
+
+	scheduler := newTransferScheduler(ctx, "上传", options.WorkerCount, options.Retries, nil)
+	for _, file := range files {
+		file, key := file, releaseDir+file.relPath
+		scheduler.submit(&transferTask{
+			name: file.path,
+			size: file.info.Size(),
+			run: func(ctx context.Context) (string, error) {
+				return key, c.putSiteFile(ctx, file, key)
+			},
+		})
+	}
+	result := scheduler.wait()
+	printTransferResult("上传", result)
+	fmt.Println()
+	return result.err("上传")
+}
+
+// putSiteFile 上传站点文件，按扩展名设置Content-Type和缓存策略，浏览器中直接显示而不是下载
+func (c *OSSClient) putSiteFile(ctx context.Context, file *localFile, key string) error {
+	contentType := siteContentType(key)
+	cacheControl := assetCacheControl
+	if strings.HasPrefix(contentType, "text/html") {
+		cacheControl = htmlCacheControl
+	}
+
+	err := c.bucket.PutObjectFromFile(key, file.path,
+		oss.WithContext(ctx),
+		oss.ContentType(contentType),
+		oss.CacheControl(cacheControl),
+	)
+	c.auditContext(ctx, AuditEntry{Op: AuditPut, Keys: []string{key}, Bytes: file.info.Size(), Detail: "deploy"}, err)
+	return err
+}
+
+// siteContentType 根据扩展名获取Content-Type，无法识别时使用application/octet-stream
+func siteContentType(key string) string {
+	if contentType := mime.TypeByExtension(path.Ext(key)); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// switchSite 把站点入口切换到指定版本
+// 入口文件是该版本index.html的副本，插入指向版本目录的<base>使相对路径的资源从版本目录加载，
+// 并在元数据中记录版本；覆盖单个文件是原子操作
+func (c *OSSClient) switchSite(ctx context.Context, site, version string) error {
+	releaseIndex := site + deployReleasesDir + version + "/" + deployIndexName
+	body, err := c.bucket.GetObject(releaseIndex, oss.WithContext(ctx))
+	if err != nil {
+		if isNotFound(err) {
+			return fmt.Errorf("版本不存在或没有%s: %s", deployIndexName, version)
+		}
+		return fmt.Errorf("读取%s失败: %v", releaseIndex, err)
+	}
+	html, err := io.ReadAll(body)
+	body.Close()
+	if err != nil {
+		return fmt.Errorf("读取%s失败: %v", releaseIndex, err)
+	}
+
+	// 使用绝对路径，入口以/www或/www/访问时相对路径的解析结果不同
+	html = injectBaseHref(html, "/"+site+deployReleasesDir+version+"/")
+	entry := site + deployIndexName
+	err = c.bucket.PutObject(entry, bytes.NewReader(html),
+		oss.WithContext(ctx),
+		oss.ContentType("text/html; charset=utf-8"),
+		oss.CacheControl(htmlCacheControl),
+		oss.Meta(metaDeployVersion, version),
+	)
+	c.audit(AuditEntry{Op: AuditPut, Keys: []string{entry}, Bytes: int64(len(html)), Detail: "deploy " + version}, err)
+	if err != nil {
+		return fmt.Errorf("切换版本失败: %v", err)
+	}
+	return nil
+}
+
+// injectBaseHref 在<head>之后插入<base>，没有<head>时插入到开头
+func injectBaseHref(html []byte, href string) []byte {
+	base := []byte(fmt.Sprintf(`<base href="%s">`, href))
+	loc := headTagPattern.FindIndex(html)
+	if loc == nil {
+		return append(base, html...)
+	}
+
+	result := make([]byte, 0, len(html)+len(base))
+	result = append(result, html[:loc[1]]...)
+	result = append(result, base...)
+	return append(result, html[loc[1]:]...)
+}
+
+// siteRelease 站点的一个版本
+type siteRelease struct {
+	Version  string
+	Deployed time.Time // 版本中index.html的修改时间，部署时最后上传，即部署完成的时间
+}
+
+// siteVersions 列出站点的所有版本，按部署时间排序
+// 自定义的版本名称不一定按名称排序，以版本中index.html的修改时间为准；没有index.html的版本（部署未完成）排在最前
+func (c *OSSClient) siteVersions(site string) ([]siteRelease, error) {
+	releases := site + deployReleasesDir
+	dirs, _, err := c.ListDir(releases)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]siteRelease, 0, len(dirs))
+	for _, dir := range dirs {
+		release := siteRelease{Version: strings.TrimSuffix(strings.TrimPrefix(dir, releases), "/")}
+		meta, err := c.bucket.GetObjectDetailedMeta(dir + deployIndexName)
+		if err == nil {
+			release.Deployed, _ = time.Parse(time.RFC1123, meta.Get(oss.HTTPHeaderLastModified))
+		} else if !isNotFound(err) {
+			return nil, fmt.Errorf("获取版本 %s 的信息失败: %v", release.Version, err)
+		}
+		versions = append(versions, release)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		if !versions[i].Deployed.Equal(versions[j].Deployed) {
+			return versions[i].Deployed.Before(versions[j].Deployed)
+		}
+		return versions[i].Version < versions[j].Version
+	})
+	return versions, nil
+}
+
+// findSiteVersion 查找版本在列表中的位置，不存在时返回-1
+func findSiteVersion(versions []siteRelease, version string) int {
+	for i, release := range versions {
+		if release.Version == version {
+			return i
+		}
+	}
+	return -1
+}
+
+// currentSiteVersion 获取站点当前的版本，站点还没有部署时返回空字符串
+func (c *OSSClient) currentSiteVersion(site string) (string, error) {
+	meta, err := c.bucket.GetObjectDetailedMeta(site + deployIndexName)
+	if err != nil {
+		if isNotFound(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("获取站点入口失败: %v", err)
+	}
+	return meta.Get(oss.HTTPHeaderOssMetaPrefix + metaDeployVersion), nil
+}
+
+// RollbackSite 把站点切换回指定版本，version为空时切换到当前版本的上一个版本
+func (c *OSSClient) RollbackSite(ctx context.Context, site, version string) error {
+	site = siteDir(site)
+	versions, err := c.siteVersions(site)
+	if err != nil {
+		return err
+	}
+	current, err := c.currentSiteVersion(site)
+	if err != nil {
+		return err
+	}
+
+	if version == "" {
+		i := findSiteVersion(versions, current)
+		if current == "" || i < 0 {
+			return fmt.Errorf("无法确定站点 /%s 的当前版本，请指定要回滚到的版本", site)
+		}
+		if i == 0 {
+			return fmt.Errorf("当前版本 %s 之前没有更早的版本", current)
+		}
+		version = versions[i-1].Version
+	} else if findSiteVersion(versions, version) < 0 {
+		return fmt.Errorf("版本不存在: %s", version)
+	}
+
+	if version == current {
+		return fmt.Errorf("站点 /%s 已经是版本 %s", site, version)
+	}
+	if err := c.switchSite(ctx, site, version); err != nil {
+		return err
+	}
+	fmt.Printf("站点 /%s 已从版本 %s 回滚到 %s\n", site, current, version)
+	return nil
+}
+
+// pruneSiteVersions 只保留最近部署的keep个版本，当前版本始终保留
+func (c *OSSClient) pruneSiteVersions(site, current string, keep int) error {
+	versions, err := c.siteVersions(site)
+	if err != nil {
+		return err
+	}
+	if len(versions) <= keep {
+		return nil
+	}
+
+	for _, release := range versions[:len(versions)-keep] {
+		if release.Version == current {
+			continue
+		}
+		if err := c.Remove(site + deployReleasesDir + release.Version + "/"); err != nil {
+			return fmt.Errorf("删除旧版本 %s 失败: %v", release.Version, err)
+		}
+		fmt.Printf("已删除旧版本: %s\n", release.Version)
+	}
+	return nil
+}
+
+// printSiteVersions 输出站点的所有版本，*标记当前版本
+func (c *OSSClient) printSiteVersions(site string) error {
+	site = siteDir(site)
+	versions, err := c.siteVersions(site)
+	if err != nil {
+		return err
+	}
+	if len(versions) == 0 {
+		fmt.Printf("站点 /%s 还没有部署过\n", site)
+		return nil
+	}
+	current, err := c.currentSiteVersion(site)
+	if err != nil {
+		return err
+	}
+
+	for _, release := range versions {
+		mark := " "
+		if release.Version == current {
+			mark = "*"
+		}
+		deployed := "未完成"
+		if !release.Deployed.IsZero() {
+			deployed = release.Deployed.Local().Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("%s %s  %s\n", mark, release.Version, deployed)
+	}
+	return nil
+}
+
+// deployCommand 处理 deploy 子命令
+func deployCommand(ctx context.Context, client *OSSClient, p *parsedArgs) error {
+	switch p.arg(0) {
+	case "list":
+		if len(p.args) != 2 {
+			return fmt.Errorf("用法: alioss deploy list <站点前缀>")
+		}
+		return client.printSiteVersions(p.arg(1))
+
+	case "rollback":
+		if len(p.args) < 2 {
+			return fmt.Errorf("用法: alioss deploy rollback <站点前缀> [版本]")
+		}
+		return client.RollbackSite(ctx, p.arg(1), p.arg(2))
+	}
+
+	if len(p.args) != 2 {
+		return fmt.Errorf("用法: alioss deploy <本地目录> <站点前缀> [--version 名称] [--keep N]")
+	}
+	options := &DeployOptions{
+		Version:     p.str("version", ""),
+		Keep:        p.int("keep", 0),
+		WorkerCount: p.int("workers", 10),
+		Retries:     flagRetries(p),
+	}
+	if p.has("exclude") {
+		options.ExcludePatterns = splitPatterns(p.str("exclude", ""))
+	}
+	return client.Deploy(ctx, p.arg(0), p.arg(1), options)
+}
@@ -0,0 +1,56 @@
+package main
+
+import "testing"
+
+func TestInjectBaseHref(t *testing.T) {
+	const href = "/www/releases/v1/"
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "head",
+			html: `<html><head><title>t</title></head></html>`,
+			want: `<html><head><base href="/www/releases/v1/"><title>t</title></head></html>`,
+		},
+		{
+			name: "head with attributes",
+			html: `<html><head lang="zh"><meta charset="utf-8"></head></html>`,
+			want: `<html><head lang="zh"><base href="/www/releases/v1/"><meta charset="utf-8"></head></html>`,
+		},
+		{
+			name: "uppercase head",
+			html: `<HTML><HEAD></HEAD></HTML>`,
+			want: `<HTML><HEAD><base href="/www/releases/v1/"></HEAD></HTML>`,
+		},
+		{
+			name: "header is not head",
+			html: `<header>x</header><head></head>`,
+			want: `<header>x</header><head><base href="/www/releases/v1/"></head>`,
+		},
+		{
+			name: "only first head",
+			html: `<head></head><p>&lt;head&gt;</p><template><head></head></template>`,
+			want: `<head><base href="/www/releases/v1/"></head><p>&lt;head&gt;</p><template><head></head></template>`,
+		},
+		{
+			name: "no head",
+			html: `<p>hello</p>`,
+			want: `<base href="/www/releases/v1/"><p>hello</p>`,
+		},
+		{
+			name: "empty",
+			html: ``,
+			want: `<base href="/www/releases/v1/">`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(injectBaseHref([]byte(tt.html), href)); got != tt.want {
+				t.Errorf("injectBaseHref(%q) = %q，期望 %q", tt.html, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// 保存文件属性使用的对象元数据名称
+const (
+	metaMode    = "Mode"
+	metaMtime   = "Mtime"
+	metaUID     = "Uid"
+	metaGID     = "Gid"
+	metaSymlink = "Symlink"
+)
+
+// 上传时符号链接的处理方式
+const (
+	SymlinkFollow = "follow" // 跟随链接，上传链接指向的文件或目录
+	SymlinkSkip   = "skip"   // 跳过符号链接
+	SymlinkStore  = "store"  // 以链接对象保存，内容为链接目标
+)
+
+// fileAttrOptions 生成保存文件权限、修改时间和所有者的对象元数据选项
+func fileAttrOptions(info os.FileInfo) []oss.Option {
+	options := []oss.Option{
+		oss.Meta(metaMode, strconv.FormatUint(uint64(unixMode(info.Mode())), 8)),
+		oss.Meta(metaMtime, formatMtime(info.ModTime())),
+	}
+
+	if uid, gid, ok := fileOwner(info); ok {
+		options = append(options,
+			oss.Meta(metaUID, strconv.Itoa(uid)),
+			oss.Meta(metaGID, strconv.Itoa(gid)),
+		)
+	}
+
+	return options
+}
+
+// applyFileAttrs 根据对象元数据恢复本地文件的权限和修改时间
+// 仅在以root身份运行时恢复文件所有者
+func applyFileAttrs(localPath string, header http.Header) error {
+	if modeStr := header.Get(oss.HTTPHeaderOssMetaPrefix + metaMode); modeStr != "" {
+		mode, err := strconv.ParseUint(modeStr, 8, 32)
+		if err != nil {
+			return fmt.Errorf("无效的文件权限元数据: %s", modeStr)
+		}
+		if err := os.Chmod(localPath, fileMode(uint32(mode))); err != nil {
+			return fmt.Errorf("设置文件权限失败: %v", err)
+		}
+	}
+
+	uidStr := header.Get(oss.HTTPHeaderOssMetaPrefix + metaUID)
+	gidStr := header.Get(oss.HTTPHeaderOssMetaPrefix + metaGID)
+	if uidStr != "" && gidStr != "" && canChown() {
+		uid, uidErr := strconv.Atoi(uidStr)
+		gid, gidErr := strconv.Atoi(gidStr)
+		if uidErr == nil && gidErr == nil {
+			if err := os.Lchown(localPath, uid, gid); err != nil {
+				return fmt.Errorf("设置文件所有者失败: %v", err)
+			}
+		}
+	}
+
+	// 修改时间最后设置，避免被前面的操作覆盖
+	if mtimeStr := header.Get(oss.HTTPHeaderOssMetaPrefix + metaMtime); mtimeStr != "" {
+		mtime, err := parseMtime(mtimeStr)
+		if err != nil {
+			return err
+		}
+		if err := os.Chtimes(localPath, mtime, mtime); err != nil {
+			return fmt.Errorf("设置文件修改时间失败: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// unixMode 将os.FileMode转换为Unix权限位（包括setuid、setgid和sticky位）
+func unixMode(mode os.FileMode) uint32 {
+	m := uint32(mode.Perm())
+	if mode&os.ModeSetuid != 0 {
+		m |= 04000
+	}
+	if mode&os.ModeSetgid != 0 {
+		m |= 02000
+	}
+	if mode&os.ModeSticky != 0 {
+		m |= 01000
+	}
+	return m
+}
+
+// fileMode 将Unix权限位转换为os.FileMode
+func fileMode(m uint32) os.FileMode {
+	mode := os.FileMode(m & 0777)
+	if m&04000 != 0 {
+		mode |= os.ModeSetuid
+	}
+	if m&02000 != 0 {
+		mode |= os.ModeSetgid
+	}
+	if m&01000 != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode
+}
+
+// formatMtime 将修改时间格式化为"秒.纳秒"形式的Unix时间戳
+func formatMtime(t time.Time) string {
+	return fmt.Sprintf("%d.%09d", t.Unix(), t.Nanosecond())
+}
+
+// parseMtime 解析formatMtime生成的时间戳，也兼容只有秒数的格式
+func parseMtime(s string) (time.Time, error) {
+	secStr, nsecStr, _ := strings.Cut(s, ".")
+	sec, err := strconv.ParseInt(secStr, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("无效的修改时间元数据: %s", s)
+	}
+
+	var nsec int64
+	if nsecStr != "" {
+		nsec, err = strconv.ParseInt(nsecStr, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("无效的修改时间元数据: %s", s)
+		}
+	}
+
+	return time.Unix(sec, nsec), nil
+}
@@ -0,0 +1,15 @@
+//go:build windows
+
+package main
+
+import "os"
+
+// fileOwner Windows上没有uid和gid
+func fileOwner(info os.FileInfo) (uid, gid int, ok bool) {
+	return 0, 0, false
+}
+
+// canChown Windows上不恢复文件所有者
+func canChown() bool {
+	return false
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// TransferFilter 按修改时间和大小筛选文件，用于上传、下载、列出和删除
+type TransferFilter struct {
+	NewerThan time.Time // 只处理在该时间之后修改的文件
+	OlderThan time.Time // 只处理在该时间之前修改的文件
+	MinSize   int64     // 最小文件大小（字节）
+	MaxSize   int64     // 最大文件大小（字节），0表示不限制
+}
+
+// parseFilterFlags 根据命令行中的时间和大小选项创建筛选条件，没有指定时返回nil
+func parseFilterFlags(p *parsedArgs) (*TransferFilter, error) {
+	var filter *TransferFilter
+	for _, name := range []string{"newer-than", "older-than", "min-size", "max-size"} {
+		if !p.has(name) {
+			continue
+		}
+		if filter == nil {
+			filter = &TransferFilter{}
+		}
+		if err := filter.set("--"+name, p.str(name, "")); err != nil {
+			return nil, err
+		}
+	}
+	return filter, nil
+}
+
+// set 设置一个筛选选项
+func (f *TransferFilter) set(name, value string) error {
+	var err error
+	switch name {
+	case "--newer-than":
+		f.NewerThan, err = parseTimeArg(value)
+	case "--older-than":
+		f.OlderThan, err = parseTimeArg(value)
+	case "--min-size":
+		f.MinSize, err = parseSize(value)
+	case "--max-size":
+		f.MaxSize, err = parseSize(value)
+		if err == nil && f.MaxSize <= 0 {
+			err = fmt.Errorf("--max-size 必须大于0")
+		}
+	default:
+		err = fmt.Errorf("未知的筛选选项: %s", name)
+	}
+	return err
+}
+
+// empty 是否没有设置任何条件
+func (f *TransferFilter) empty() bool {
+	return f == nil || (f.NewerThan.IsZero() && f.OlderThan.IsZero() && f.MinSize <= 0 && f.MaxSize <= 0)
+}
+
+// match 判断文件是否满足条件，filter为nil时总是满足
+func (f *TransferFilter) match(modTime time.Time, size int64) bool {
+	if f == nil {
+		return true
+	}
+	if !f.NewerThan.IsZero() && modTime.Before(f.NewerThan) {
+		return false
+	}
+	if !f.OlderThan.IsZero() && !modTime.Before(f.OlderThan) {
+		return false
+	}
+	if size < f.MinSize {
+		return false
+	}
+	if f.MaxSize > 0 && size > f.MaxSize {
+		return false
+	}
+	return true
+}
+
+// matchObject 判断OSS列举结果中的文件是否满足条件
+func (f *TransferFilter) matchObject(object oss.ObjectProperties) bool {
+	return f.match(object.LastModified, object.Size)
+}
+
+// parseTimeArg 解析时间参数，支持 2006-01-02、2006-01-02 15:04、RFC3339，以及 24h、7d 这样表示多久以前的相对时间
+func parseTimeArg(value string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", "2006-01-02 15:04", "2006-01-02 15:04:05"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if age, err := parseAge(value); err == nil {
+		return time.Now().Add(-age), nil
+	}
+	return time.Time{}, fmt.Errorf("无效的时间: %s，示例: 2024-03-01、\"2024-03-01 08:00\"、24h、7d", value)
+}
+
+// parseSize 解析文件大小，支持 K、M、G、T 单位（按1024计算），可以带B或iB后缀，如 512K、1.5MB、2GiB
+func parseSize(value string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "IB"), "B")
+
+	unit := int64(1)
+	if s != "" {
+		switch s[len(s)-1] {
+		case 'K':
+			unit = 1 << 10
+		case 'M':
+			unit = 1 << 20
+		case 'G':
+			unit = 1 << 30
+		case 'T':
+			unit = 1 << 40
+		}
+		if unit > 1 {
+			s = s[:len(s)-1]
+		}
+	}
+
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("无效的大小: %s，示例: 1024、512K、1.5M、2G", value)
+	}
+	return int64(n * float64(unit)), nil
+}
+
+// filterObjects 筛选满足条件的文件
+func filterObjects(objects []oss.ObjectProperties, filter *TransferFilter) []oss.ObjectProperties {
+	if filter.empty() {
+		return objects
+	}
+	var matched []oss.ObjectProperties
+	for _, object := range objects {
+		if filter.matchObject(object) {
+			matched = append(matched, object)
+		}
+	}
+	return matched
+}
+
+// ListFilesFiltered 列出指定前缀下满足时间和大小条件的文件
+func (c *OSSClient) ListFilesFiltered(prefix string, filter *TransferFilter) ([]string, error) {
+	if filter.empty() {
+		return c.ListFiles(prefix)
+	}
+
+	objects, err := c.ListObjects(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, object := range filterObjects(objects, filter) {
+		files = append(files, object.Key)
+	}
+	return files, nil
+}
+
+// objectMatchesFilter 获取单个文件的信息并判断是否满足条件
+func (c *OSSClient) objectMatchesFilter(ossPath string, filter *TransferFilter) (bool, error) {
+	if filter.empty() {
+		return true, nil
+	}
+
+	meta, err := c.bucket.GetObjectDetailedMeta(ossPath)
+	if err != nil {
+		return false, fmt.Errorf("获取文件信息失败: %v", err)
+	}
+	size, _ := strconv.ParseInt(meta.Get(oss.HTTPHeaderContentLength), 10, 64)
+	modTime, _ := time.Parse(time.RFC1123, meta.Get(oss.HTTPHeaderLastModified))
+	return filter.match(modTime, size), nil
+}
+
+// DeleteFiltered 删除满足标签、时间和大小条件的文件
+// 路径以斜杠结尾或包含*时按前缀删除，否则只检查并删除单个文件
+func (c *OSSClient) DeleteFiltered(ossPath string, tagFilters []TagFilter, filter *TransferFilter) error {
+	// 标准化OSS路径，去除前导斜杠
+	ossPath = strings.TrimPrefix(ossPath, "/")
+
+	var files []string
+	if ossPath != "" && !strings.HasSuffix(ossPath, "/") && !strings.Contains(ossPath, "*") {
+		matched, err := c.objectMatchesFilter(ossPath, filter)
+		if err != nil {
+			return err
+		}
+		if matched && len(tagFilters) > 0 {
+			tags, err := c.GetTags(ossPath)
+			if err != nil {
+				return err
+			}
+			matched = matchTags(tags, tagFilters)
+		}
+		if !matched {
+			return fmt.Errorf("文件不满足筛选条件: %s", ossPath)
+		}
+		files = []string{ossPath}
+	} else {
+		prefix := strings.Split(ossPath, "*")[0]
+		var err error
+		files, err = c.ListFilesFiltered(prefix, filter)
+		if err != nil {
+			return fmt.Errorf("获取文件列表失败: %v", err)
+		}
+		files, err = c.FilterByTags(files, tagFilters, 0)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(files) == 0 {
+		return fmt.Errorf("未找到匹配的文件")
+	}
+	return c.deleteObjects(files)
+}
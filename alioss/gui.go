@@ -0,0 +1,667 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// guiRootNode 目录树中表示Bucket根目录的节点，其它节点的ID就是目录前缀
+const guiRootNode = "/"
+
+// guiDefaultProfile 配置选择框中表示顶层配置的选项
+const guiDefaultProfile = "(默认配置)"
+
+// guiWorkerCount 图形界面中同时进行的传输数
+const guiWorkerCount = 3
+
+// guiURLExpire 复制的签名链接的有效期
+const guiURLExpire = time.Hour
+
+// guiTransfer 传输列表中的一项
+type guiTransfer struct {
+	name     string
+	status   string
+	progress float64
+}
+
+// guiJob 一个待传输的文件，run执行传输并通过progress报告进度
+type guiJob struct {
+	name string
+	size int64
+	run  func(ctx context.Context, progress func(done, total int64)) error
+}
+
+// ossBrowser 图形界面的主窗口：配置选择、目录树、文件列表和传输列表
+// 控件只能在界面协程中修改，后台协程修改mu保护的状态后通过do提交界面更新
+type ossBrowser struct {
+	app        fyne.App
+	window     fyne.Window
+	open       func(profile string) (guiStore, error)
+	do         func(fn func())
+	background sync.WaitGroup // 正在运行的后台协程
+
+	mu               sync.Mutex
+	store            guiStore
+	children         map[string][]string // 已列举的目录树节点的子目录
+	prefix           string              // 文件列表显示的目录
+	objects          []oss.ObjectProperties
+	selected         string // 文件列表中选中的文件
+	transfers        []*guiTransfer
+	transfersPending bool // 已提交尚未执行的传输列表刷新
+
+	profileSelect *widget.Select
+	tree          *widget.Tree
+	fileList      *widget.List
+	transferList  *widget.List
+	status        *widget.Label
+}
+
+// newOSSBrowser 创建主窗口并打开profile对应的存储
+// profiles为可选的命名配置，空字符串表示顶层配置；open根据配置名打开存储
+// do在界面协程中执行函数，通常为fyne.Do
+func newOSSBrowser(a fyne.App, profiles []string, profile string, open func(profile string) (guiStore, error), do func(fn func())) *ossBrowser {
+	g := &ossBrowser{
+		app:      a,
+		window:   a.NewWindow("alioss"),
+		open:     open,
+		do:       do,
+		children: make(map[string][]string),
+		status:   widget.NewLabel(""),
+	}
+
+	options := make([]string, len(profiles))
+	for i, name := range profiles {
+		options[i] = profileLabel(name)
+	}
+	g.profileSelect = widget.NewSelect(options, func(label string) {
+		g.switchProfile(profileFromLabel(label))
+	})
+
+	g.tree = widget.NewTree(g.childNodes,
+		func(widget.TreeNodeID) bool { return true },
+		func(bool) fyne.CanvasObject { return widget.NewLabel("") },
+		func(node widget.TreeNodeID, _ bool, object fyne.CanvasObject) {
+			object.(*widget.Label).SetText(g.nodeName(node))
+		})
+	g.tree.OnSelected = func(node widget.TreeNodeID) {
+		g.openPrefix(nodePrefix(node))
+	}
+
+	g.fileList = widget.NewList(
+		func() int {
+			g.mu.Lock()
+			defer g.mu.Unlock()
+			return len(g.objects)
+		},
+		func() fyne.CanvasObject {
+			return container.NewBorder(nil, nil, nil, widget.NewLabel(""), widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, object fyne.CanvasObject) {
+			g.mu.Lock()
+			if id >= len(g.objects) {
+				g.mu.Unlock()
+				return
+			}
+			item, prefix := g.objects[id], g.prefix
+			g.mu.Unlock()
+
+			row := object.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(strings.TrimPrefix(item.Key, prefix))
+			row.Objects[1].(*widget.Label).SetText(formatSize(item.Size) + "  " + item.LastModified.Local().Format("2006-01-02 15:04"))
+		})
+	g.fileList.OnSelected = func(id widget.ListItemID) {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		if id < len(g.objects) {
+			g.selected = g.objects[id].Key
+		}
+	}
+	g.fileList.OnUnselected = func(widget.ListItemID) {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		g.selected = ""
+	}
+
+	g.transferList = widget.NewList(
+		func() int {
+			g.mu.Lock()
+			defer g.mu.Unlock()
+			return len(g.transfers)
+		},
+		func() fyne.CanvasObject {
+			return container.NewVBox(
+				container.NewBorder(nil, nil, nil, widget.NewLabel(""), widget.NewLabel("")),
+				widget.NewProgressBar())
+		},
+		func(id widget.ListItemID, object fyne.CanvasObject) {
+			g.mu.Lock()
+			if id >= len(g.transfers) {
+				g.mu.Unlock()
+				return
+			}
+			t := *g.transfers[id]
+			g.mu.Unlock()
+
+			item := object.(*fyne.Container)
+			row := item.Objects[0].(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(t.name)
+			row.Objects[1].(*widget.Label).SetText(t.status)
+			item.Objects[1].(*widget.ProgressBar).SetValue(t.progress)
+		})
+
+	toolbar := container.NewHBox(
+		g.profileSelect,
+		widget.NewButton("刷新", g.refresh),
+		widget.NewButton("上传", g.chooseUpload),
+		widget.NewButton("下载", g.chooseDownload),
+		widget.NewButton("删除", g.confirmDelete),
+		widget.NewButton("复制链接", g.copySignedURL),
+	)
+	browser := container.NewHSplit(g.tree, g.fileList)
+	browser.Offset = 0.3
+	body := container.NewVSplit(browser, g.transferList)
+	body.Offset = 0.7
+	g.window.SetContent(container.NewBorder(toolbar, g.status, nil, nil, body))
+	g.window.Resize(fyne.NewSize(960, 640))
+
+	// 拖入的文件和目录上传到当前目录
+	g.window.SetOnDropped(func(_ fyne.Position, uris []fyne.URI) {
+		var paths []string
+		for _, uri := range uris {
+			paths = append(paths, uri.Path())
+		}
+		g.upload(paths)
+	})
+
+	if len(options) > 0 {
+		g.profileSelect.SetSelected(profileLabel(profile))
+	}
+	return g
+}
+
+// profileLabel 配置名在选择框中显示的文字
+func profileLabel(name string) string {
+	if name == "" {
+		return guiDefaultProfile
+	}
+	return name
+}
+
+// profileFromLabel 选择框中的文字对应的配置名
+func profileFromLabel(label string) string {
+	if label == guiDefaultProfile {
+		return ""
+	}
+	return label
+}
+
+// nodePrefix 目录树节点对应的前缀
+func nodePrefix(node string) string {
+	if node == guiRootNode {
+		return ""
+	}
+	return node
+}
+
+// nodeName 目录树节点显示的名称
+func (g *ossBrowser) nodeName(node string) string {
+	if node == guiRootNode {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		if g.store != nil {
+			return g.store.Name()
+		}
+		return node
+	}
+	return path.Base(node) + "/"
+}
+
+// switchProfile 打开另一个配置的存储，清空目录缓存后显示根目录
+func (g *ossBrowser) switchProfile(profile string) {
+	store, err := g.open(profile)
+	if err != nil {
+		dialog.ShowError(err, g.window)
+		return
+	}
+
+	g.mu.Lock()
+	g.store = store
+	g.children = make(map[string][]string)
+	g.mu.Unlock()
+
+	g.window.SetTitle("alioss - " + store.Name())
+	g.tree.Refresh()
+	g.tree.UnselectAll()
+	g.openPrefix("")
+}
+
+// childNodes 目录树节点的子目录，未列举时在后台列举，完成后刷新目录树
+func (g *ossBrowser) childNodes(node widget.TreeNodeID) []widget.TreeNodeID {
+	if node == "" {
+		return []string{guiRootNode}
+	}
+
+	g.mu.Lock()
+	dirs, ok := g.children[node]
+	if !ok {
+		// 先占位，避免重复列举
+		g.children[node] = nil
+	}
+	g.mu.Unlock()
+
+	if !ok {
+		g.goBackground(func() { g.list(nodePrefix(node)) })
+	}
+	return dirs
+}
+
+// goBackground 在后台协程中执行耗时的操作
+func (g *ossBrowser) goBackground(fn func()) {
+	g.background.Add(1)
+	go func() {
+		defer g.background.Done()
+		fn()
+	}()
+}
+
+// openPrefix 在文件列表中显示目录的内容
+func (g *ossBrowser) openPrefix(prefix string) {
+	g.mu.Lock()
+	g.prefix = prefix
+	g.selected = ""
+	g.objects = nil
+	g.mu.Unlock()
+
+	g.fileList.UnselectAll()
+	g.fileList.Refresh()
+	g.setStatus("正在列出 /" + prefix)
+	g.goBackground(func() { g.list(prefix) })
+}
+
+// list 在后台列出目录，更新目录树的子目录，目录正在文件列表中显示时同时更新文件列表
+func (g *ossBrowser) list(prefix string) {
+	g.mu.Lock()
+	store := g.store
+	g.mu.Unlock()
+	if store == nil {
+		return
+	}
+
+	dirs, objects, err := store.ListDir(prefix)
+	if err != nil {
+		g.do(func() { g.setStatus(fmt.Sprintf("列出 /%s 失败: %v", prefix, err)) })
+		return
+	}
+
+	node := prefix
+	if node == "" {
+		node = guiRootNode
+	}
+
+	g.mu.Lock()
+	if g.store != store {
+		// 列举期间切换了配置
+		g.mu.Unlock()
+		return
+	}
+	g.children[node] = dirs
+	current := g.prefix == prefix
+	if current {
+		g.objects = objects
+	}
+	g.mu.Unlock()
+
+	g.do(func() {
+		g.tree.Refresh()
+		if current {
+			g.fileList.Refresh()
+			g.setStatus(fmt.Sprintf("/%s: %d 个目录，%d 个文件", prefix, len(dirs), len(objects)))
+		}
+	})
+}
+
+// refresh 清空目录缓存，重新列出当前目录
+func (g *ossBrowser) refresh() {
+	g.mu.Lock()
+	g.children = make(map[string][]string)
+	prefix := g.prefix
+	g.mu.Unlock()
+
+	g.tree.Refresh()
+	g.openPrefix(prefix)
+}
+
+// setStatus 更新状态栏
+func (g *ossBrowser) setStatus(text string) {
+	g.status.SetText(text)
+}
+
+// refreshTransfers 提交传输列表的刷新，已有尚未执行的刷新时不重复提交
+func (g *ossBrowser) refreshTransfers() {
+	g.mu.Lock()
+	pending := g.transfersPending
+	g.transfersPending = true
+	g.mu.Unlock()
+	if pending {
+		return
+	}
+
+	g.do(func() {
+		g.mu.Lock()
+		g.transfersPending = false
+		g.mu.Unlock()
+		g.transferList.Refresh()
+	})
+}
+
+// selectedKey 选中的文件
+func (g *ossBrowser) selectedKey() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.selected
+}
+
+// chooseUpload 通过文件对话框选择要上传的文件
+func (g *ossBrowser) chooseUpload() {
+	dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, g.window)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		reader.Close()
+		g.upload([]string{reader.URI().Path()})
+	}, g.window)
+}
+
+// upload 上传本地文件或目录到当前目录，目录保持原有结构
+func (g *ossBrowser) upload(paths []string) {
+	g.mu.Lock()
+	store, prefix := g.store, g.prefix
+	g.mu.Unlock()
+	if store == nil {
+		return
+	}
+
+	var jobs []guiJob
+	add := func(localPath, key string, size int64) {
+		jobs = append(jobs, guiJob{
+			name: key,
+			size: size,
+			run: func(ctx context.Context, progress func(done, total int64)) error {
+				return store.Upload(ctx, localPath, key, progress)
+			},
+		})
+	}
+
+	for _, localPath := range paths {
+		info, err := os.Stat(localPath)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("读取文件信息失败: %v", err), g.window)
+			return
+		}
+		base := filepath.Base(localPath)
+		if !info.IsDir() {
+			add(localPath, prefix+base, info.Size())
+			continue
+		}
+		_, err = walkLocalDir(localPath, &UploadOptions{}, func(file *localFile) error {
+			add(file.path, prefix+base+"/"+file.relPath, file.info.Size())
+			return nil
+		})
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("遍历目录失败: %v", err), g.window)
+			return
+		}
+	}
+
+	g.runJobs("上传", jobs, func() {
+		g.refresh()
+	})
+}
+
+// chooseDownload 选择本地目录，下载选中的文件
+func (g *ossBrowser) chooseDownload() {
+	key := g.selectedKey()
+	if key == "" {
+		dialog.ShowInformation("下载", "请先在文件列表中选择要下载的文件", g.window)
+		return
+	}
+
+	dialog.ShowFolderOpen(func(dir fyne.ListableURI, err error) {
+		if err != nil {
+			dialog.ShowError(err, g.window)
+			return
+		}
+		if dir != nil {
+			g.download(key, filepath.Join(dir.Path(), path.Base(key)))
+		}
+	}, g.window)
+}
+
+// download 下载文件到本地路径
+func (g *ossBrowser) download(key, localPath string) {
+	g.mu.Lock()
+	store := g.store
+	g.mu.Unlock()
+
+	g.runJobs("下载", []guiJob{{
+		name: key,
+		run: func(ctx context.Context, progress func(done, total int64)) error {
+			return store.Download(ctx, key, localPath, progress)
+		},
+	}}, nil)
+}
+
+// runJobs 使用传输调度器在后台执行传输，传输列表显示每个文件的状态和进度，全部结束后调用done
+func (g *ossBrowser) runJobs(verb string, jobs []guiJob, done func()) {
+	if len(jobs) == 0 {
+		return
+	}
+
+	// 不同目录下可能有同名的文件，任务以作业的序号命名
+	items := make([]*guiTransfer, len(jobs))
+	g.mu.Lock()
+	for i, job := range jobs {
+		items[i] = &guiTransfer{name: verb + " " + job.name, status: "等待中"}
+		g.transfers = append(g.transfers, items[i])
+	}
+	g.mu.Unlock()
+	g.transferList.Refresh()
+
+	update := func(t *guiTransfer, status string, progress float64) {
+		g.mu.Lock()
+		t.status = status
+		if progress >= 0 {
+			t.progress = progress
+		}
+		g.mu.Unlock()
+		g.refreshTransfers()
+	}
+
+	scheduler := newTransferScheduler(context.Background(), verb, guiWorkerCount, 0, func(event TransferEvent) {
+		index, err := strconv.Atoi(event.Name)
+		if err != nil || index < 0 || index >= len(items) {
+			return
+		}
+		t := items[index]
+		switch event.Status {
+		case TransferDone:
+			update(t, "完成", 1)
+		case TransferFailed:
+			update(t, fmt.Sprintf("失败: %v", event.Err), -1)
+		case TransferRetry:
+			update(t, fmt.Sprintf("%v后重试 (%d/%d)", event.Delay, event.Attempt, event.Retries), 0)
+		case TransferCanceled:
+			update(t, "已取消", -1)
+		}
+	})
+
+	g.goBackground(func() {
+		for i, job := range jobs {
+			t := items[i]
+			scheduler.submit(&transferTask{
+				name: strconv.Itoa(i),
+				size: job.size,
+				run: func(ctx context.Context) (string, error) {
+					update(t, verb+"中", 0)
+					// 进度每变化1%刷新一次
+					last := -1
+					err := job.run(ctx, func(done, total int64) {
+						if total <= 0 {
+							return
+						}
+						if percent := int(done * 100 / total); percent != last {
+							last = percent
+							update(t, verb+"中", float64(done)/float64(total))
+						}
+					})
+					if err != nil {
+						return "", err
+					}
+					return job.name, nil
+				},
+			})
+		}
+
+		result := scheduler.wait()
+		status := fmt.Sprintf("%s完成: %d 个文件成功", verb, result.Done)
+		if result.Failed > 0 {
+			status += fmt.Sprintf("，%d 个文件失败", result.Failed)
+		}
+		g.do(func() {
+			g.setStatus(status)
+			if done != nil {
+				done()
+			}
+		})
+	})
+}
+
+// confirmDelete 确认后删除选中的文件，没有选中文件时删除当前目录
+func (g *ossBrowser) confirmDelete() {
+	g.mu.Lock()
+	store, key, prefix := g.store, g.selected, g.prefix
+	g.mu.Unlock()
+	if store == nil {
+		return
+	}
+
+	message := fmt.Sprintf("确定删除文件 %s 吗？", key)
+	if key == "" {
+		if prefix == "" {
+			dialog.ShowInformation("删除", "请先选择要删除的文件或目录", g.window)
+			return
+		}
+		key = prefix
+		message = fmt.Sprintf("确定删除目录 %s 下的所有文件吗？", prefix)
+	}
+
+	dialog.ShowConfirm("删除", message, func(ok bool) {
+		if !ok {
+			return
+		}
+		g.goBackground(func() {
+			err := store.Remove(key)
+			g.do(func() {
+				if err != nil {
+					dialog.ShowError(fmt.Errorf("删除失败: %v", err), g.window)
+					return
+				}
+				g.setStatus("已删除: " + key)
+				if key == prefix {
+					g.tree.Select(parentNode(prefix))
+				}
+				g.refresh()
+			})
+		})
+	}, g.window)
+}
+
+// parentNode 目录的上级目录在目录树中的节点
+func parentNode(prefix string) string {
+	parent := path.Dir(strings.TrimSuffix(prefix, "/"))
+	if parent == "." || parent == "/" {
+		return guiRootNode
+	}
+	return parent + "/"
+}
+
+// copySignedURL 生成选中文件的签名URL并复制到剪贴板
+func (g *ossBrowser) copySignedURL() {
+	g.mu.Lock()
+	store, key := g.store, g.selected
+	g.mu.Unlock()
+	if key == "" {
+		dialog.ShowInformation("复制链接", "请先在文件列表中选择文件", g.window)
+		return
+	}
+
+	url, err := store.SignURL(key, guiURLExpire)
+	if err != nil {
+		dialog.ShowError(err, g.window)
+		return
+	}
+	g.app.Clipboard().SetContent(url)
+	g.setStatus(fmt.Sprintf("已复制 %s 的签名链接，%v内有效", key, guiURLExpire))
+}
+
+// guiCommand 处理 gui 子命令，demo为true时使用内存中的示例数据
+func guiCommand(options *ClientOptions, demo bool) error {
+	a, err := newFyneApp()
+	if err != nil {
+		return err
+	}
+
+	var profiles []string
+	var open func(profile string) (guiStore, error)
+	if demo {
+		store := newMemoryStore("demo", map[string]string{
+			"readme.txt":            "alioss demo\n",
+			"releases/v1.0/app.tar": "v1.0",
+			"releases/v1.1/app.tar": "v1.1",
+			"logs/2024/app.log":     "started\n",
+		})
+		profiles = []string{""}
+		open = func(string) (guiStore, error) { return store, nil }
+	} else {
+		config, err := loadConfig(&ClientOptions{ConfigFile: options.ConfigFile})
+		if err != nil {
+			return fmt.Errorf("加载配置失败: %v", err)
+		}
+		profiles = []string{""}
+		for name := range config.Profiles {
+			profiles = append(profiles, name)
+		}
+		sort.Strings(profiles[1:])
+
+		open = func(profile string) (guiStore, error) {
+			client, err := NewOSSClient(&ClientOptions{ConfigFile: options.ConfigFile, Profile: profile})
+			if err != nil {
+				return nil, err
+			}
+			client.auditLog.command = "gui"
+			return &ossGUIStore{client: client}, nil
+		}
+	}
+
+	g := newOSSBrowser(a, profiles, options.Profile, open, fyne.Do)
+	g.window.ShowAndRun()
+	return nil
+}
@@ -0,0 +1,13 @@
+//go:build gui
+
+package main
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/app"
+)
+
+// newFyneApp 创建图形界面应用
+func newFyneApp() (fyne.App, error) {
+	return app.NewWithID("com.godailytools.alioss"), nil
+}
@@ -0,0 +1,14 @@
+//go:build !gui
+
+package main
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+)
+
+// newFyneApp 没有使用gui标签编译时不包含图形界面的驱动
+func newFyneApp() (fyne.App, error) {
+	return nil, fmt.Errorf("当前程序编译时没有包含图形界面，请使用 go build -tags gui 重新编译（需要安装OpenGL和X11开发库）")
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// guiStore 图形界面使用的存储操作，界面测试时可以换成内存中的实现
+type guiStore interface {
+	Name() string
+	ListDir(prefix string) ([]string, []oss.ObjectProperties, error)
+	Upload(ctx context.Context, localPath, key string, progress func(done, total int64)) error
+	Download(ctx context.Context, key, localPath string, progress func(done, total int64)) error
+	Remove(key string) error // 以斜杠结尾时删除前缀下的所有文件
+	SignURL(key string, expire time.Duration) (string, error)
+}
+
+// progressFunc 把SDK的进度事件转换为回调
+type progressFunc func(done, total int64)
+
+// ProgressChanged 实现oss.ProgressListener
+func (f progressFunc) ProgressChanged(event *oss.ProgressEvent) {
+	if f != nil {
+		f(event.ConsumedBytes, event.TotalBytes)
+	}
+}
+
+// ossGUIStore 使用OSSClient的实现，上传下载与命令行使用相同的元数据、审计和回收站处理
+type ossGUIStore struct {
+	client *OSSClient
+}
+
+// Name Bucket名称
+func (s *ossGUIStore) Name() string {
+	return s.client.config.Bucket
+}
+
+// ListDir 列出前缀下的一层内容
+func (s *ossGUIStore) ListDir(prefix string) ([]string, []oss.ObjectProperties, error) {
+	return s.client.ListDir(prefix)
+}
+
+// Upload 上传单个本地文件
+func (s *ossGUIStore) Upload(ctx context.Context, localPath, key string, progress func(done, total int64)) error {
+	info, err := os.Stat(localPath)
+	if err != nil {
+		return fmt.Errorf("读取文件信息失败: %v", err)
+	}
+	file := &localFile{path: localPath, relPath: filepath.Base(localPath), info: info}
+	return s.client.putLocalFile(ctx, file, key, &UploadOptions{}, oss.Progress(progressFunc(progress)))
+}
+
+// Download 下载单个文件
+func (s *ossGUIStore) Download(ctx context.Context, key, localPath string, progress func(done, total int64)) error {
+	return s.client.downloadObject(ctx, key, localPath, oss.Progress(progressFunc(progress)))
+}
+
+// Remove 删除文件或前缀，配置中开启回收站时移动到回收站
+func (s *ossGUIStore) Remove(key string) error {
+	return s.client.Remove(key)
+}
+
+// SignURL 生成签名URL
+func (s *ossGUIStore) SignURL(key string, expire time.Duration) (string, error) {
+	return s.client.GetSignedURL(key, expire)
+}
+
+// memoryObject 内存存储中的一个文件
+type memoryObject struct {
+	data    []byte
+	modTime time.Time
+}
+
+// memoryStore 保存在内存中的存储，用于界面测试和 gui --demo
+type memoryStore struct {
+	mu      sync.Mutex
+	name    string
+	objects map[string]memoryObject
+}
+
+// newMemoryStore 创建内存存储，files为初始的文件路径和内容
+func newMemoryStore(name string, files map[string]string) *memoryStore {
+	s := &memoryStore{name: name, objects: make(map[string]memoryObject)}
+	for key, content := range files {
+		s.objects[key] = memoryObject{data: []byte(content), modTime: time.Now()}
+	}
+	return s
+}
+
+// Name 存储名称
+func (s *memoryStore) Name() string {
+	return s.name
+}
+
+// ListDir 按"/"列出前缀下的一层内容
+func (s *memoryStore) ListDir(prefix string) ([]string, []oss.ObjectProperties, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]string, 0, len(s.objects))
+	for key := range s.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var dirs []string
+	var objects []oss.ObjectProperties
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) || key == prefix {
+			continue
+		}
+		if i := strings.Index(key[len(prefix):], "/"); i >= 0 {
+			dir := key[:len(prefix)+i+1]
+			if len(dirs) == 0 || dirs[len(dirs)-1] != dir {
+				dirs = append(dirs, dir)
+			}
+			continue
+		}
+		object := s.objects[key]
+		objects = append(objects, oss.ObjectProperties{Key: key, Size: int64(len(object.data)), LastModified: object.modTime})
+	}
+	return dirs, objects, nil
+}
+
+// Upload 读取本地文件保存到内存
+func (s *memoryStore) Upload(ctx context.Context, localPath, key string, progress func(done, total int64)) error {
+	data, err := os.ReadFile(localPath)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.objects[key] = memoryObject{data: data, modTime: time.Now()}
+	s.mu.Unlock()
+
+	if progress != nil {
+		progress(int64(len(data)), int64(len(data)))
+	}
+	return nil
+}
+
+// Download 把内存中的文件写入本地
+func (s *memoryStore) Download(ctx context.Context, key, localPath string, progress func(done, total int64)) error {
+	s.mu.Lock()
+	object, ok := s.objects[key]
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("文件不存在: %s", key)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(localPath, object.data, 0644); err != nil {
+		return err
+	}
+	if progress != nil {
+		progress(int64(len(object.data)), int64(len(object.data)))
+	}
+	return nil
+}
+
+// Remove 删除文件或前缀下的所有文件
+func (s *memoryStore) Remove(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !strings.HasSuffix(key, "/") {
+		if _, ok := s.objects[key]; !ok {
+			return fmt.Errorf("文件不存在: %s", key)
+		}
+		delete(s.objects, key)
+		return nil
+	}
+
+	deleted := 0
+	for k := range s.objects {
+		if strings.HasPrefix(k, key) {
+			delete(s.objects, k)
+			deleted++
+		}
+	}
+	if deleted == 0 {
+		return fmt.Errorf("未找到匹配的文件")
+	}
+	return nil
+}
+
+// SignURL 返回带过期时间的内存地址，只用于展示
+func (s *memoryStore) SignURL(key string, expire time.Duration) (string, error) {
+	return fmt.Sprintf("memory://%s/%s?Expires=%d", s.name, key, time.Now().Add(expire).Unix()), nil
+}
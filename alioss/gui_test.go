@@ -0,0 +1,284 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+)
+
+// testBrowser 测试用的主窗口，测试所在的协程充当界面协程
+type testBrowser struct {
+	*ossBrowser
+
+	uiMu    sync.Mutex
+	pending []func() // 后台协程提交的界面更新
+}
+
+// newTestBrowser 使用测试驱动和内存存储创建主窗口
+func newTestBrowser(t *testing.T, files map[string]string) (*testBrowser, *memoryStore) {
+	t.Helper()
+	a := test.NewTempApp(t)
+	store := newMemoryStore("test-bucket", files)
+	g := &testBrowser{}
+	g.ossBrowser = newOSSBrowser(a, []string{""}, "", func(string) (guiStore, error) { return store, nil }, g.do)
+	t.Cleanup(func() {
+		g.background.Wait()
+		g.window.Close()
+	})
+	return g, store
+}
+
+// do 记录界面更新，由runUI在测试协程中执行
+func (g *testBrowser) do(fn func()) {
+	g.uiMu.Lock()
+	defer g.uiMu.Unlock()
+	g.pending = append(g.pending, fn)
+}
+
+// runUI 执行已提交的界面更新
+func (g *testBrowser) runUI() {
+	for {
+		g.uiMu.Lock()
+		pending := g.pending
+		g.pending = nil
+		g.uiMu.Unlock()
+		if len(pending) == 0 {
+			return
+		}
+		for _, fn := range pending {
+			fn()
+		}
+	}
+}
+
+// settle 等待所有后台协程结束并执行它们提交的界面更新
+func (g *testBrowser) settle() {
+	for {
+		g.background.Wait()
+		g.runUI()
+		g.uiMu.Lock()
+		idle := len(g.pending) == 0
+		g.uiMu.Unlock()
+		if idle {
+			return
+		}
+	}
+}
+
+// waitFor 等待后台列举或传输完成，超时后测试失败
+func waitFor(t *testing.T, g *testBrowser, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for g.runUI(); !cond(); g.runUI() {
+		if time.Now().After(deadline) {
+			t.Fatalf("等待%s超时", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// fileNames 文件列表中显示的文件路径
+func fileNames(g *testBrowser) []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	names := make([]string, len(g.objects))
+	for i, object := range g.objects {
+		names[i] = object.Key
+	}
+	return names
+}
+
+// treeChildren 目录树节点已列举的子目录，未列举时返回false
+func treeChildren(g *testBrowser, node string) ([]string, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	dirs, ok := g.children[node]
+	return dirs, ok && dirs != nil
+}
+
+// selectFile 在文件列表中选中文件
+func selectFile(t *testing.T, g *testBrowser, key string) {
+	t.Helper()
+	for i, name := range fileNames(g) {
+		if name == key {
+			g.fileList.Select(i)
+			if g.selectedKey() != key {
+				t.Fatalf("选中的文件为 %q，期望 %q", g.selectedKey(), key)
+			}
+			return
+		}
+	}
+	t.Fatalf("文件列表中没有 %s: %v", key, fileNames(g))
+}
+
+// tapDialogButton 点击窗口最上层对话框中的按钮
+func tapDialogButton(t *testing.T, g *testBrowser, text string) {
+	t.Helper()
+	overlay := g.window.Canvas().Overlays().Top()
+	if overlay == nil {
+		t.Fatal("没有显示对话框")
+	}
+	for _, object := range test.LaidOutObjects(overlay) {
+		if button, ok := object.(*widget.Button); ok && button.Text == text {
+			test.Tap(button)
+			return
+		}
+	}
+	t.Fatalf("对话框中没有 %q 按钮", text)
+}
+
+func TestGUITreeListing(t *testing.T) {
+	g, _ := newTestBrowser(t, map[string]string{
+		"readme.txt":            "hello",
+		"releases/v1.0/app.tar": "v1.0",
+		"releases/v1.1/app.tar": "v1.1",
+		"logs/app.log":          "started",
+	})
+
+	if title := g.window.Title(); title != "alioss - test-bucket" {
+		t.Errorf("窗口标题为 %q", title)
+	}
+	waitFor(t, g, "列出根目录", func() bool { return len(fileNames(g)) == 1 })
+	if names := fileNames(g); names[0] != "readme.txt" {
+		t.Errorf("根目录的文件为 %v", names)
+	}
+
+	root := g.childNodes("")
+	if len(root) != 1 || root[0] != guiRootNode {
+		t.Fatalf("目录树的根节点为 %v", root)
+	}
+	if name := g.nodeName(guiRootNode); name != "test-bucket" {
+		t.Errorf("根节点显示为 %q", name)
+	}
+	waitFor(t, g, "列出根目录的子目录", func() bool { _, ok := treeChildren(g, guiRootNode); return ok })
+	dirs, _ := treeChildren(g, guiRootNode)
+	if strings.Join(dirs, ",") != "logs/,releases/" {
+		t.Errorf("根目录的子目录为 %v", dirs)
+	}
+
+	g.childNodes("releases/")
+	waitFor(t, g, "列出releases/的子目录", func() bool { _, ok := treeChildren(g, "releases/"); return ok })
+	dirs, _ = treeChildren(g, "releases/")
+	if strings.Join(dirs, ",") != "releases/v1.0/,releases/v1.1/" {
+		t.Errorf("releases/的子目录为 %v", dirs)
+	}
+	if name := g.nodeName("releases/v1.1/"); name != "v1.1/" {
+		t.Errorf("节点显示为 %q", name)
+	}
+
+	g.tree.Select("releases/v1.1/")
+	waitFor(t, g, "列出releases/v1.1/", func() bool { return len(fileNames(g)) == 1 })
+	if names := fileNames(g); names[0] != "releases/v1.1/app.tar" {
+		t.Errorf("releases/v1.1/的文件为 %v", names)
+	}
+}
+
+func TestGUIUpload(t *testing.T) {
+	g, store := newTestBrowser(t, map[string]string{"docs/readme.txt": "hello"})
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(file, []byte("aaa"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(dir, "site")
+	if err := os.MkdirAll(filepath.Join(sub, "css"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sub, "index.html"), []byte("<html>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sub, "css", "main.css"), []byte("body{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	g.tree.Select("docs/")
+	waitFor(t, g, "列出docs/", func() bool { return len(fileNames(g)) == 1 })
+
+	g.upload([]string{file, sub})
+	// 上传完成后刷新文件列表
+	waitFor(t, g, "上传完成", func() bool { return len(fileNames(g)) == 2 })
+
+	for key, want := range map[string]string{
+		"docs/a.txt":             "aaa",
+		"docs/site/index.html":   "<html>",
+		"docs/site/css/main.css": "body{}",
+		"docs/readme.txt":        "hello",
+	} {
+		store.mu.Lock()
+		object, ok := store.objects[key]
+		store.mu.Unlock()
+		if !ok || string(object.data) != want {
+			t.Errorf("%s 的内容为 %q，期望 %q", key, object.data, want)
+		}
+	}
+	if names := fileNames(g); strings.Join(names, ",") != "docs/a.txt,docs/readme.txt" {
+		t.Errorf("上传后docs/的文件为 %v", names)
+	}
+
+	g.mu.Lock()
+	transfers := len(g.transfers)
+	g.mu.Unlock()
+	if transfers != 3 {
+		t.Errorf("传输列表中有 %d 项，期望 3", transfers)
+	}
+}
+
+func TestGUIDeleteAfterConfirm(t *testing.T) {
+	g, store := newTestBrowser(t, map[string]string{
+		"a.txt": "a",
+		"b.txt": "b",
+	})
+	waitFor(t, g, "列出根目录", func() bool { return len(fileNames(g)) == 2 })
+
+	// 取消时不删除
+	selectFile(t, g, "a.txt")
+	g.confirmDelete()
+	tapDialogButton(t, g, "No")
+	g.settle()
+	store.mu.Lock()
+	_, ok := store.objects["a.txt"]
+	store.mu.Unlock()
+	if !ok || len(fileNames(g)) != 2 {
+		t.Fatalf("取消后文件列表为 %v", fileNames(g))
+	}
+
+	g.confirmDelete()
+	tapDialogButton(t, g, "Yes")
+	waitFor(t, g, "删除完成", func() bool { return len(fileNames(g)) == 1 })
+	if names := fileNames(g); names[0] != "b.txt" {
+		t.Errorf("删除后的文件为 %v", names)
+	}
+	store.mu.Lock()
+	_, ok = store.objects["a.txt"]
+	store.mu.Unlock()
+	if ok {
+		t.Error("a.txt 没有从存储中删除")
+	}
+}
+
+func TestGUICopySignedURL(t *testing.T) {
+	g, _ := newTestBrowser(t, map[string]string{"dist/app.zip": "zip"})
+
+	g.tree.Select("dist/")
+	waitFor(t, g, "列出dist/", func() bool { return len(fileNames(g)) == 1 })
+
+	clipboard := g.app.Clipboard()
+	clipboard.SetContent("")
+	selectFile(t, g, "dist/app.zip")
+	g.copySignedURL()
+
+	url := clipboard.Content()
+	if !strings.HasPrefix(url, "memory://test-bucket/dist/app.zip?Expires=") {
+		t.Errorf("剪贴板中的链接为 %q", url)
+	}
+	if !strings.Contains(g.status.Text, "dist/app.zip") {
+		t.Errorf("状态栏为 %q", g.status.Text)
+	}
+}
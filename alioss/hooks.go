@@ -0,0 +1,315 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultHookCommands 配置文件中的钩子默认只对这些命令生效
+var defaultHookCommands = []string{"upload", "download", "replicate"}
+
+// defaultWebhookRetries Webhook请求失败时的默认重试次数
+const defaultWebhookRetries = 3
+
+// HookConfig 命令完成后的通知配置
+type HookConfig struct {
+	OnComplete     string   `json:"onComplete,omitempty"`     // 命令完成后运行的本地命令，标准输入为JSON格式的结果汇总
+	Webhook        string   `json:"webhook,omitempty"`        // 命令完成后POST结果汇总的URL
+	WebhookRetries int      `json:"webhookRetries,omitempty"` // Webhook失败时的重试次数，默认3次
+	Commands       []string `json:"commands,omitempty"`       // 对哪些命令生效，默认为upload、download、replicate
+}
+
+// HookOptions 命令行中指定的钩子，优先于配置文件
+type HookOptions struct {
+	OnComplete string
+	Webhook    string
+	Disable    bool // --no-hooks，不运行配置文件中的钩子
+}
+
+// TransferRecord 汇总中的一个文件
+type TransferRecord struct {
+	Op     string `json:"op"`
+	Bucket string `json:"bucket"`
+	Key    string `json:"key"`
+	Dest   string `json:"dest,omitempty"`
+	Bytes  int64  `json:"bytes,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// TransferSummary 命令完成后传给钩子的结果汇总
+type TransferSummary struct {
+	Command      string           `json:"command"`
+	Args         []string         `json:"args"`
+	Profile      string           `json:"profile,omitempty"`
+	Bucket       string           `json:"bucket"`
+	User         string           `json:"user"`
+	Host         string           `json:"host,omitempty"`
+	Status       string           `json:"status"` // ok 或 error
+	ExitCode     int              `json:"exitCode"`
+	Start        time.Time        `json:"start"`
+	End          time.Time        `json:"end"`
+	Duration     float64          `json:"durationSeconds"`
+	FileCount    int              `json:"fileCount"`
+	Bytes        int64            `json:"bytes"`
+	FailureCount int              `json:"failureCount"`
+	Files        []TransferRecord `json:"files"`
+	Failures     []TransferRecord `json:"failures"`
+}
+
+// transferStats 统计一次命令中传输和修改的文件
+type transferStats struct {
+	mu       sync.Mutex
+	start    time.Time
+	files    []TransferRecord
+	failures []TransferRecord
+	bytes    int64
+}
+
+// newTransferStats 创建统计，从当前时间开始计时
+func newTransferStats() *transferStats {
+	return &transferStats{start: time.Now(), files: []TransferRecord{}, failures: []TransferRecord{}}
+}
+
+// add 记录一次操作，批量操作的每个文件单独记录
+func (s *transferStats) add(entry AuditEntry, err error) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range entry.Keys {
+		record := TransferRecord{Op: entry.Op, Bucket: entry.Bucket, Key: key, Dest: entry.Dest}
+		if len(entry.Keys) == 1 {
+			record.Bytes = entry.Bytes
+		}
+		if err != nil {
+			record.Error = err.Error()
+			s.failures = append(s.failures, record)
+			continue
+		}
+		s.files = append(s.files, record)
+		s.bytes += record.Bytes
+	}
+}
+
+// addContext 同add，ctx属于调度器中任务的一次尝试时先缓存，任务结束时只计入最后一次尝试
+func (s *transferStats) addContext(ctx context.Context, entry AuditEntry, err error) {
+	if s == nil {
+		return
+	}
+	if attempt, ok := ctx.Value(statsAttemptKey{}).(*statsAttempt); ok {
+		attempt.mu.Lock()
+		attempt.records = append(attempt.records, statsRecord{stats: s, entry: entry, err: err})
+		attempt.mu.Unlock()
+		return
+	}
+	s.add(entry, err)
+}
+
+// statsAttemptKey 上下文中保存当前尝试的统计缓存的键
+type statsAttemptKey struct{}
+
+// statsRecord 一条缓存的统计记录
+type statsRecord struct {
+	stats *transferStats
+	entry AuditEntry
+	err   error
+}
+
+// statsAttempt 调度器中任务一次尝试的统计缓存，重试时丢弃之前的尝试
+type statsAttempt struct {
+	mu      sync.Mutex
+	records []statsRecord
+}
+
+// commit 将缓存的记录计入统计
+func (a *statsAttempt) commit() {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, record := range a.records {
+		record.stats.add(record.entry, record.err)
+	}
+	a.records = nil
+}
+
+// summary 生成结果汇总
+func (s *transferStats) summary() TransferSummary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	end := time.Now()
+	return TransferSummary{
+		Start:        s.start,
+		End:          end,
+		Duration:     end.Sub(s.start).Seconds(),
+		FileCount:    len(s.files),
+		Bytes:        s.bytes,
+		FailureCount: len(s.failures),
+		Files:        append(s.files[:0:0], s.files...),
+		Failures:     append(s.failures[:0:0], s.failures...),
+	}
+}
+
+// commandHooks 一次命令的钩子
+type commandHooks struct {
+	client  *OSSClient
+	command string
+	args    []string
+	config  HookConfig
+	once    sync.Once
+}
+
+// newCommandHooks 合并命令行和配置文件中的钩子，没有需要运行的钩子时返回nil
+// 命令行中指定的钩子对任意命令生效，配置文件中的钩子只对commands中的命令生效
+func (c *OSSClient) newCommandHooks(command string, args []string, options *HookOptions) *commandHooks {
+	var config HookConfig
+	if c.config.Hooks != nil && !options.Disable {
+		commands := c.config.Hooks.Commands
+		if len(commands) == 0 {
+			commands = defaultHookCommands
+		}
+		for _, name := range commands {
+			if name == command {
+				config = *c.config.Hooks
+				break
+			}
+		}
+	}
+	if options.OnComplete != "" {
+		config.OnComplete = options.OnComplete
+	}
+	if options.Webhook != "" {
+		config.Webhook = options.Webhook
+	}
+	if config.OnComplete == "" && config.Webhook == "" {
+		return nil
+	}
+	if config.WebhookRetries <= 0 {
+		config.WebhookRetries = defaultWebhookRetries
+	}
+
+	c.stats = newTransferStats()
+	return &commandHooks{client: c, command: command, args: args, config: config}
+}
+
+// run 生成结果汇总并运行钩子，只运行一次
+// 钩子失败只输出警告，不影响命令的退出码
+func (h *commandHooks) run(exitCode int) {
+	h.once.Do(func() {
+		summary := h.client.stats.summary()
+		summary.Command = h.command
+		summary.Args = h.args
+		summary.Profile = h.client.profile
+		summary.Bucket = h.client.config.Bucket
+		summary.User = h.client.auditLog.user
+		summary.Host = h.client.auditLog.host
+		summary.ExitCode = exitCode
+		summary.Status = "ok"
+		if exitCode != 0 || summary.FailureCount > 0 {
+			summary.Status = "error"
+		}
+
+		data, err := json.MarshalIndent(summary, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "警告: 生成结果汇总失败: %v\n", err)
+			return
+		}
+
+		if h.config.OnComplete != "" {
+			if err := runCompleteCommand(h.config.OnComplete, data, &summary); err != nil {
+				fmt.Fprintf(os.Stderr, "警告: 完成命令运行失败: %v\n", err)
+			}
+		}
+		if h.config.Webhook != "" {
+			if err := postWebhook(h.config.Webhook, data, h.config.WebhookRetries); err != nil {
+				fmt.Fprintf(os.Stderr, "警告: Webhook通知失败: %v\n", err)
+			}
+		}
+	})
+}
+
+// runCompleteCommand 通过shell运行完成命令，标准输入为结果汇总，常用字段同时通过环境变量传递
+func runCompleteCommand(command string, data []byte, summary *TransferSummary) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		"ALIOSS_COMMAND="+summary.Command,
+		"ALIOSS_STATUS="+summary.Status,
+		"ALIOSS_BUCKET="+summary.Bucket,
+		"ALIOSS_FILES="+strconv.Itoa(summary.FileCount),
+		"ALIOSS_BYTES="+strconv.FormatInt(summary.Bytes, 10),
+		"ALIOSS_FAILURES="+strconv.Itoa(summary.FailureCount),
+	)
+	return cmd.Run()
+}
+
+// postWebhook POST结果汇总，网络错误、429和5xx时按1s、2s、4s...的间隔重试
+func postWebhook(url string, data []byte, retries int) error {
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+	delay := time.Second
+
+	var lastErr error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			fmt.Fprintf(os.Stderr, "Webhook通知失败: %v，%v后重试 (%d/%d)\n", lastErr, delay, attempt, retries)
+			time.Sleep(delay)
+			delay *= 2
+		}
+
+		req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("无效的Webhook地址: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "alioss")
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return nil
+		}
+		lastErr = fmt.Errorf("HTTP %s", resp.Status)
+		if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+			// 其它4xx错误重试也不会成功
+			return lastErr
+		}
+	}
+	return lastErr
+}
+
+// onExit 调用exit退出前运行，用于在出错退出时也能运行钩子
+var onExit func(code int)
+
+// exit 运行退出前的钩子后退出程序
+func exit(code int) {
+	if onExit != nil {
+		onExit(code)
+	}
+	os.Exit(code)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// partialFiles 正在写入的本地文件，强制退出前删除
+var partialFiles = struct {
+	sync.Mutex
+	paths map[string]bool
+}{paths: make(map[string]bool)}
+
+// trackPartial 登记正在写入的文件，返回的函数在写入结束后调用
+func trackPartial(path string) func() {
+	partialFiles.Lock()
+	partialFiles.paths[path] = true
+	partialFiles.Unlock()
+
+	return func() {
+		partialFiles.Lock()
+		delete(partialFiles.paths, path)
+		partialFiles.Unlock()
+	}
+}
+
+// removePartialFiles 删除所有未写完的文件
+func removePartialFiles() {
+	partialFiles.Lock()
+	defer partialFiles.Unlock()
+	for path := range partialFiles.paths {
+		os.Remove(path)
+	}
+}
+
+// withInterrupt 返回收到SIGINT或SIGTERM时取消的context
+// 第一次信号取消context，进行中的传输中止并清理后由调用方输出汇总；第二次信号删除未写完的文件后立即退出
+// 返回的stop函数停止监听信号
+func withInterrupt(parent context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(parent)
+
+	sigChan := make(chan os.Signal, 2)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case <-sigChan:
+		case <-done:
+			return
+		}
+		fmt.Fprintln(os.Stderr, "\n正在停止，等待进行中的传输结束（再按一次Ctrl-C强制退出）...")
+		cancel()
+
+		select {
+		case <-sigChan:
+			removePartialFiles()
+			fmt.Fprintln(os.Stderr, "已强制退出")
+			os.Exit(130)
+		case <-done:
+		}
+	}()
+
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() {
+			signal.Stop(sigChan)
+			close(done)
+			cancel()
+		})
+	}
+}
+
+// canceledError 操作被中断时返回的错误，done为已完成的文件数，remaining为未处理的文件数
+func canceledError(done, remaining int) error {
+	return fmt.Errorf("操作已中断: %d 个文件已完成，%d 个文件未处理", done, remaining)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// completeFunc 根据光标前的内容返回补全后的内容和候选列表
+// 返回的before为替换后的光标前内容，没有可补全内容时原样返回
+type completeFunc func(before string) (string, []string)
+
+// lineEditor 交互模式的行编辑器，终端中使用term.Terminal提供光标移动、历史记录和Tab补全
+// 标准输入不是终端时退化为逐行读取
+type lineEditor struct {
+	reader   *bufio.Reader
+	fd       int
+	terminal *term.Terminal // 标准输入不是终端时为nil
+	complete completeFunc
+}
+
+// newLineEditor 创建从标准输入读取的行编辑器
+func newLineEditor(history term.History, complete completeFunc) *lineEditor {
+	e := &lineEditor{
+		reader:   bufio.NewReader(os.Stdin),
+		fd:       int(os.Stdin.Fd()),
+		complete: complete,
+	}
+	if term.IsTerminal(e.fd) && term.IsTerminal(int(os.Stdout.Fd())) {
+		e.terminal = term.NewTerminal(struct {
+			io.Reader
+			io.Writer
+		}{&interruptReader{r: os.Stdin}, os.Stdout}, "")
+		e.terminal.History = history
+		e.terminal.AutoCompleteCallback = e.autoComplete
+	}
+	return e
+}
+
+// readLine 读取一行输入，输入结束（Ctrl+D）时返回io.EOF，Ctrl+C放弃当前行
+func (e *lineEditor) readLine(prompt string) (string, error) {
+	if e.terminal == nil {
+		line, err := e.reader.ReadString('\n')
+		if err == io.EOF && line != "" {
+			err = nil
+		}
+		return strings.TrimRight(line, "\r\n"), err
+	}
+
+	state, err := term.MakeRaw(e.fd)
+	if err != nil {
+		return "", fmt.Errorf("设置终端模式失败: %v", err)
+	}
+	defer term.Restore(e.fd, state)
+
+	if width, height, err := term.GetSize(e.fd); err == nil && width > 0 {
+		e.terminal.SetSize(width, height)
+	}
+	e.terminal.SetPrompt(prompt)
+	return e.terminal.ReadLine()
+}
+
+// autoComplete 实现term.Terminal的AutoCompleteCallback，按Tab时补全，有多个候选时列出
+func (e *lineEditor) autoComplete(line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' || e.complete == nil {
+		return "", 0, false
+	}
+	before, candidates := e.complete(line[:pos])
+	if len(candidates) > 1 && before == line[:pos] {
+		fmt.Fprintln(e.terminal, strings.Join(candidates, "  "))
+	}
+	return before + line[pos:], len(before), true
+}
+
+// interruptReader 把Ctrl+C转换为Ctrl+E和Ctrl+U，即清空当前行
+// term.Terminal收到Ctrl+C时会结束输入，交互模式中Ctrl+C只放弃当前行
+type interruptReader struct {
+	r       io.Reader
+	pending []byte
+}
+
+// Read 实现io.Reader
+func (r *interruptReader) Read(p []byte) (int, error) {
+	if len(r.pending) == 0 {
+		buf := make([]byte, len(p))
+		n, err := r.r.Read(buf)
+		if n == 0 {
+			return 0, err
+		}
+		r.pending = bytes.ReplaceAll(buf[:n], []byte{3}, []byte{5, 21})
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
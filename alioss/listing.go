@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// listChanSize 并行列举时缓存的文件数，调用方处理较慢时列举协程在此等待
+const listChanSize = 1000
+
+// WalkObjects 列举前缀下的文件，每得到一页就对其中的文件调用fn，不需要等待全部列举完成
+// parallel大于1时先按"/"列出下一层子目录，再用parallel个协程同时列举各个子目录，此时文件的顺序不固定
+// fn只在调用方的协程中顺序调用，返回错误时停止列举并返回该错误
+func (c *OSSClient) WalkObjects(ctx context.Context, prefix string, parallel int, fn func(object oss.ObjectProperties) error) error {
+	// 标准化前缀，去除前导斜杠
+	prefix = strings.TrimPrefix(prefix, "/")
+
+	if parallel <= 1 {
+		return c.listPages(ctx, prefix, "", func(page oss.ListObjectsResult) error {
+			for _, object := range page.Objects {
+				if err := fn(object); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+
+	// 第一层的文件直接处理，子目录留给列举协程
+	var subPrefixes []string
+	err := c.listPages(ctx, prefix, "/", func(page oss.ListObjectsResult) error {
+		subPrefixes = append(subPrefixes, page.CommonPrefixes...)
+		for _, object := range page.Objects {
+			if err := fn(object); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil || len(subPrefixes) == 0 {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	prefixChan := make(chan string, len(subPrefixes))
+	for _, subPrefix := range subPrefixes {
+		prefixChan <- subPrefix
+	}
+	close(prefixChan)
+
+	if parallel > len(subPrefixes) {
+		parallel = len(subPrefixes)
+	}
+	objectChan := make(chan oss.ObjectProperties, listChanSize)
+	errChan := make(chan error, parallel)
+	var wg sync.WaitGroup
+	for i := 0; i < parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for subPrefix := range prefixChan {
+				err := c.listPages(ctx, subPrefix, "", func(page oss.ListObjectsResult) error {
+					for _, object := range page.Objects {
+						select {
+						case objectChan <- object:
+						case <-ctx.Done():
+							return ctx.Err()
+						}
+					}
+					return nil
+				})
+				if err != nil {
+					errChan <- err
+					cancel()
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(objectChan)
+	}()
+
+	// fn出错后继续读取，直到列举协程全部退出
+	var fnErr error
+	for object := range objectChan {
+		if fnErr != nil {
+			continue
+		}
+		if fnErr = fn(object); fnErr != nil {
+			cancel()
+		}
+	}
+	if fnErr != nil {
+		return fnErr
+	}
+
+	select {
+	case err := <-errChan:
+		return err
+	default:
+		return nil
+	}
+}
+
+// listPages 分页列举前缀下的内容，每得到一页调用一次fn
+func (c *OSSClient) listPages(ctx context.Context, prefix, delimiter string, fn func(page oss.ListObjectsResult) error) error {
+	marker := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		options := []oss.Option{oss.Marker(marker), oss.Prefix(prefix), oss.WithContext(ctx)}
+		if delimiter != "" {
+			options = append(options, oss.Delimiter(delimiter))
+		}
+		lsRes, err := c.bucket.ListObjects(options...)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("列举文件失败: %v", err)
+		}
+
+		if err := fn(lsRes); err != nil {
+			return err
+		}
+
+		if !lsRes.IsTruncated {
+			return nil
+		}
+		marker = lsRes.NextMarker
+	}
+}
@@ -1,15 +1,20 @@
 package main
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
-	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/aliyun/aliyun-oss-go-sdk/oss"
@@ -17,45 +22,70 @@ import (
 
 // OSSConfig 存储OSS配置信息
 type OSSConfig struct {
-	Bucket   string `json:"bucket"`
-	ID       string `json:"id"`
-	Secret   string `json:"secret"`
-	EndPoint string `json:"endPoint"`
+	Bucket      string               `json:"bucket"`
+	ID          string               `json:"id"`
+	Secret      string               `json:"secret"`
+	EndPoint    string               `json:"endPoint"`
+	Trash       bool                 `json:"trash,omitempty"`       // 删除时移动到回收站而不是永久删除
+	TrashPrefix string               `json:"trashPrefix,omitempty"` // 回收站前缀，默认为 .trash/
+	AuditLog    string               `json:"auditLog,omitempty"`    // 审计日志路径，默认为 ~/.alioss_audit.jsonl
+	Hooks       *HookConfig          `json:"hooks,omitempty"`       // 命令完成后的通知
+	Profiles    map[string]OSSConfig `json:"profiles,omitempty"`    // 命名配置，未填写的字段使用顶层配置
 }
 
 // OSSClient 封装OSS客户端
 type OSSClient struct {
-	client *oss.Client
-	bucket *oss.Bucket
-	config OSSConfig
+	client     *oss.Client
+	bucket     *oss.Bucket
+	config     OSSConfig
+	configFile string         // 配置文件路径，为空时使用默认路径
+	profile    string         // 使用的命名配置
+	auditLog   *auditLogger   // 修改操作的审计日志
+	stats      *transferStats // 传给完成钩子的传输统计，没有钩子时为nil
 }
 
 // UploadOptions 上传选项
 type UploadOptions struct {
-	ExcludePatterns []string // 排除的文件或目录模式
-	Incremental     bool     // 是否增量上传
-	Concurrent      bool     // 是否并发上传
-	WorkerCount     int      // 并发上传的工作协程数
+	ExcludePatterns []string        // 排除的文件或目录模式
+	Incremental     bool            // 是否增量上传
+	Concurrent      bool            // 是否并发上传
+	WorkerCount     int             // 并发上传的工作协程数
+	PartSize        int64           // 流式上传的分片大小（字节）
+	Symlinks        string          // 符号链接处理方式: follow、skip、store
+	Archive         string          // 打包上传的压缩格式: tar.gz、zip，为空时逐个文件上传
+	Tags            []oss.Tag       // 上传时设置的对象标签
+	Template        string          // OSS路径模板，如 releases/{date:2006-01-02}/{basename}
+	IfExists        string          // OSS上已存在同名文件时的处理策略，为空时直接覆盖
+	Filter          *TransferFilter // 只上传满足时间和大小条件的文件
+	Retries         int             // 失败重试次数，0使用默认值，负数表示不重试
+	Manifest        *uploadManifest // 记录上传文件的SHA256，并保存为对象元数据
 }
 
-// uploadTask 表示一个上传任务
-type uploadTask struct {
-	localPath     string
-	ossPath       string
-	needUpload    bool
-	err           error
-	needCheckHash bool
+// localFile 扫描本地目录得到的待上传文件
+type localFile struct {
+	path       string      // 本地路径
+	relPath    string      // 相对于上传目录的路径（使用正斜杠）
+	info       os.FileInfo // 文件信息，跟随符号链接时为链接目标的信息
+	linkTarget string      // 以链接对象保存时的链接目标
 }
 
 // DownloadOptions 下载选项
 type DownloadOptions struct {
-	Concurrent  bool // 是否并发下载
-	WorkerCount int  // 并发下载的工作协程数
+	Concurrent   bool            // 是否并发下载
+	WorkerCount  int             // 并发下载的工作协程数
+	VersionID    string          // 下载指定版本（仅用于单个文件）
+	Extract      bool            // 下载压缩包并解压到本地目录
+	TagFilters   []TagFilter     // 只下载标签满足条件的文件
+	IfExists     string          // 本地已存在同名文件时的处理策略，为空时直接覆盖
+	Filter       *TransferFilter // 只下载满足时间和大小条件的文件
+	Retries      int             // 失败重试次数，0使用默认值，负数表示不重试
+	ListParallel int             // 按子目录并行列举的协程数，0或1时顺序列举
 }
 
 // ClientOptions 客户端选项
 type ClientOptions struct {
 	ConfigFile string // 配置文件路径
+	Profile    string // 使用的命名配置，为空时使用顶层配置
 }
 
 // NewOSSClient 创建一个新的OSS客户端
@@ -75,13 +105,48 @@ func NewOSSClient(options *ClientOptions) (*OSSClient, error) {
 		return nil, fmt.Errorf("获取Bucket失败: %v", err)
 	}
 
+	var configFile, profile string
+	if options != nil {
+		configFile = options.ConfigFile
+		profile = options.Profile
+	}
+
 	return &OSSClient{
-		client: client,
-		bucket: bucket,
-		config: config,
+		client:     client,
+		bucket:     bucket,
+		config:     config,
+		configFile: configFile,
+		profile:    profile,
+		auditLog:   newAuditLogger(config.AuditLog, profile),
 	}, nil
 }
 
+// openProfile 使用同一个配置文件中的命名配置创建另一个客户端
+// profile为空时使用当前客户端的配置，bucket不为空时替换配置中的Bucket
+func (c *OSSClient) openProfile(profile, bucketName string) (*OSSClient, error) {
+	if profile == "" {
+		profile = c.profile
+	}
+
+	client, err := NewOSSClient(&ClientOptions{ConfigFile: c.configFile, Profile: profile})
+	if err != nil {
+		return nil, err
+	}
+
+	if bucketName != "" && bucketName != client.config.Bucket {
+		bucket, err := client.client.Bucket(bucketName)
+		if err != nil {
+			return nil, fmt.Errorf("获取Bucket失败: %v", err)
+		}
+		client.bucket = bucket
+		client.config.Bucket = bucketName
+	}
+
+	client.auditLog.command = c.auditLog.command
+	client.stats = c.stats
+	return client, nil
+}
+
 // 从配置文件加载配置
 func loadConfig(options *ClientOptions) (OSSConfig, error) {
 	var configPath string
@@ -113,6 +178,17 @@ func loadConfig(options *ClientOptions) (OSSConfig, error) {
 		return OSSConfig{}, fmt.Errorf("解析配置文件失败: %v", err)
 	}
 
+	// 使用命名配置
+	if options != nil && options.Profile != "" {
+		config, err = selectProfile(config, options.Profile)
+		if err != nil {
+			return OSSConfig{}, err
+		}
+	}
+	if config.TrashPrefix == "" {
+		config.TrashPrefix = defaultTrashPrefix
+	}
+
 	// 验证配置是否完整
 	if config.Bucket == "" || config.ID == "" || config.Secret == "" || config.EndPoint == "" {
 		return OSSConfig{}, fmt.Errorf("配置文件不完整，请确保包含bucket、id、secret和endPoint字段")
@@ -121,17 +197,66 @@ func loadConfig(options *ClientOptions) (OSSConfig, error) {
 	return config, nil
 }
 
+// selectProfile 选择命名配置，未填写的字段继承顶层配置
+func selectProfile(config OSSConfig, name string) (OSSConfig, error) {
+	profile, ok := config.Profiles[name]
+	if !ok {
+		return OSSConfig{}, fmt.Errorf("配置文件中没有名为 %s 的配置", name)
+	}
+
+	if profile.Bucket == "" {
+		profile.Bucket = config.Bucket
+	}
+	if profile.ID == "" {
+		profile.ID = config.ID
+	}
+	if profile.Secret == "" {
+		profile.Secret = config.Secret
+	}
+	if profile.EndPoint == "" {
+		profile.EndPoint = config.EndPoint
+	}
+	if profile.TrashPrefix == "" {
+		profile.TrashPrefix = config.TrashPrefix
+	}
+	if profile.AuditLog == "" {
+		profile.AuditLog = config.AuditLog
+	}
+	if profile.Hooks == nil {
+		profile.Hooks = config.Hooks
+	}
+	profile.Profiles = nil
+	return profile, nil
+}
+
 // UploadFile 上传本地文件到OSS
-func (c *OSSClient) UploadFile(localPath, ossPath string, options *UploadOptions) error {
+func (c *OSSClient) UploadFile(ctx context.Context, localPath, ossPath string, options *UploadOptions) error {
 	// 检查是否为目录
 	fileInfo, err := os.Stat(localPath)
 	if err != nil {
 		return fmt.Errorf("读取文件信息失败: %v", err)
 	}
 
+	file := &localFile{path: localPath, info: fileInfo}
+	if options != nil && options.Symlinks == SymlinkStore {
+		if linkInfo, err := os.Lstat(localPath); err == nil && linkInfo.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(localPath)
+			if err != nil {
+				return fmt.Errorf("读取符号链接失败: %v", err)
+			}
+			file = &localFile{path: localPath, info: linkInfo, linkTarget: target}
+		}
+	}
+
 	// 如果是目录，则递归上传目录中的文件
-	if fileInfo.IsDir() {
-		return c.UploadDirectory(localPath, ossPath, options)
+	if fileInfo.IsDir() && file.linkTarget == "" {
+		return c.UploadDirectory(ctx, localPath, ossPath, options)
+	}
+
+	// 检查时间和大小条件
+	if options != nil && !options.Filter.match(file.info.ModTime(), file.info.Size()) {
+		fmt.Printf("跳过(不满足筛选条件): %s\n", localPath)
+		return nil
 	}
 
 	// 如果ossPath为空，使用本地文件名
@@ -144,37 +269,83 @@ func (c *OSSClient) UploadFile(localPath, ossPath string, options *UploadOptions
 
 	// 如果是增量上传，先检查文件是否存在且内容相同
 	if options != nil && options.Incremental {
-		needUpload, err := c.needUpload(localPath, ossPath)
+		needUpload, err := c.needUploadLocal(file, ossPath)
 		if err != nil {
 			return fmt.Errorf("检查文件是否需要上传失败: %v", err)
 		}
 		if !needUpload {
 			fmt.Printf("跳过(无变化): %s\n", ossPath)
-			return nil
+			return options.Manifest.addLocal(file, ossPath)
 		}
 	}
 
+	conflicts := newConflictLog(uploadIfExists(options))
+	_, err = runTransfer(ctx, "上传", uploadRetries(options), &transferTask{
+		name: ossPath,
+		run: func(ctx context.Context) (string, error) {
+			return c.putLocalFileIfExists(ctx, file, ossPath, options, conflicts)
+		},
+	})
+	conflicts.printSummary()
+	if err != nil {
+		return fmt.Errorf("上传文件失败: %v", err)
+	}
+
+	return nil
+}
+
+// uploadIfExists 获取上传的冲突策略
+func uploadIfExists(options *UploadOptions) string {
+	if options == nil {
+		return ""
+	}
+	return options.IfExists
+}
+
+// putLocalFile 上传单个本地文件，并将文件属性保存为对象元数据
+// 以链接对象保存的符号链接，上传的内容为链接目标，extra为额外的请求选项
+func (c *OSSClient) putLocalFile(ctx context.Context, file *localFile, ossPath string, options *UploadOptions, extra ...oss.Option) error {
 	// 使用中文名时需要指定Content-Disposition
 	ossOptions := []oss.Option{
-		oss.ContentDisposition(fmt.Sprintf("attachment; filename=\"%s\"", filepath.Base(localPath))),
+		oss.WithContext(ctx),
+		oss.ContentDisposition(fmt.Sprintf("attachment; filename=\"%s\"", filepath.Base(file.path))),
 	}
+	ossOptions = append(ossOptions, fileAttrOptions(file.info)...)
+	ossOptions = append(ossOptions, uploadTagOptions(options)...)
+	ossOptions = append(ossOptions, extra...)
 
-	err = c.bucket.PutObjectFromFile(ossPath, localPath, ossOptions...)
+	// 生成清单时同时保存SHA256元数据
+	sum, manifestOptions, err := manifestUploadOptions(file, options)
 	if err != nil {
-		return fmt.Errorf("上传文件失败: %v", err)
+		return err
 	}
+	ossOptions = append(ossOptions, manifestOptions...)
 
-	return nil
+	if file.linkTarget != "" {
+		ossOptions = append(ossOptions, oss.Meta(metaSymlink, "1"))
+		err = c.bucket.PutObject(ossPath, strings.NewReader(file.linkTarget), ossOptions...)
+		c.auditContext(ctx, AuditEntry{Op: AuditPut, Keys: []string{ossPath}, Bytes: int64(len(file.linkTarget)), Detail: "symlink"}, err)
+	} else {
+		err = c.bucket.PutObjectFromFile(ossPath, file.path, ossOptions...)
+		c.auditContext(ctx, AuditEntry{Op: AuditPut, Keys: []string{ossPath}, Bytes: file.info.Size()}, err)
+	}
+	if err == nil && sum != "" {
+		options.Manifest.add(ossPath, sum)
+	}
+	return err
 }
 
-// needUpload 检查文件是否需要上传（文件不存在或内容有变化）
-func (c *OSSClient) needUpload(localPath, ossPath string) (bool, error) {
-	// 1. 计算本地文件的MD5
-	localMD5, err := fileMD5(localPath)
+// needUploadLocal 检查本地文件或链接对象是否需要上传
+func (c *OSSClient) needUploadLocal(file *localFile, ossPath string) (bool, error) {
+	localMD5, err := localContentMD5(file)
 	if err != nil {
 		return true, fmt.Errorf("计算本地文件MD5失败: %v", err)
 	}
+	return c.needUploadMD5(localMD5, ossPath)
+}
 
+// needUploadMD5 根据本地内容的MD5检查文件是否需要上传
+func (c *OSSClient) needUploadMD5(localMD5, ossPath string) (bool, error) {
 	// 2. 检查远程文件是否存在
 	exist, err := c.bucket.IsObjectExist(ossPath)
 	if err != nil {
@@ -216,8 +387,17 @@ func fileMD5(filePath string) (string, error) {
 	return hex.EncodeToString(hash.Sum(nil)), nil
 }
 
+// localContentMD5 计算本地文件或链接对象内容的MD5
+func localContentMD5(file *localFile) (string, error) {
+	if file.linkTarget != "" {
+		sum := md5.Sum([]byte(file.linkTarget))
+		return hex.EncodeToString(sum[:]), nil
+	}
+	return fileMD5(file.path)
+}
+
 // UploadDirectory 上传目录及其所有文件到OSS
-func (c *OSSClient) UploadDirectory(localDirPath, ossDirPath string, options *UploadOptions) error {
+func (c *OSSClient) UploadDirectory(ctx context.Context, localDirPath, ossDirPath string, options *UploadOptions) error {
 	// 确保OSS路径以斜杠结尾
 	if ossDirPath != "" && !strings.HasSuffix(ossDirPath, "/") {
 		ossDirPath += "/"
@@ -241,346 +421,197 @@ func (c *OSSClient) UploadDirectory(localDirPath, ossDirPath string, options *Up
 		fmt.Println("使用增量上传模式")
 	}
 
-	// 如果启用并发上传
+	// 未启用并发上传时按顺序逐个上传
+	workerCount := 1
 	if options != nil && options.Concurrent {
-		workerCount := options.WorkerCount
+		workerCount = options.WorkerCount
 		if workerCount <= 0 {
 			workerCount = 10 // 默认10个并发
 		}
 		fmt.Printf("使用并发上传模式 (工作协程数: %d)\n", workerCount)
-		return c.concurrentUploadDirectory(localDirPath, ossDirPath, options, workerCount)
 	}
 
-	// 未启用并发上传，使用普通上传
-	return c.sequentialUploadDirectory(localDirPath, ossDirPath, options)
+	return c.uploadDirectory(ctx, localDirPath, ossDirPath, options, workerCount)
 }
 
-// sequentialUploadDirectory 顺序上传目录中的文件（原有的实现）
-func (c *OSSClient) sequentialUploadDirectory(localDirPath, ossDirPath string, options *UploadOptions) error {
-	// 统计上传文件数量和排除文件数量
-	var uploadCount, excludeCount, skipCount int
+// uploadDirectory 边扫描目录边通过调度器上传文件
+func (c *OSSClient) uploadDirectory(ctx context.Context, localDirPath, ossDirPath string, options *UploadOptions, workerCount int) error {
+	conflicts := newConflictLog(uploadIfExists(options))
+	scheduler := newTransferScheduler(ctx, "上传", workerCount, uploadRetries(options), nil)
 
-	err := filepath.Walk(localDirPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
+	excludeCount, walkErr := walkLocalDir(localDirPath, options, func(file *localFile) error {
+		// 收到中断信号后不再扫描和上传新的文件
+		if err := ctx.Err(); err != nil {
 			return err
 		}
 
-		// 跳过目录本身
-		if info.IsDir() {
-			return nil
-		}
-
-		// 获取文件的绝对路径
-		absPath, err := filepath.Abs(path)
-		if err != nil {
-			return fmt.Errorf("获取绝对路径失败: %v", err)
-		}
-
-		// 计算相对路径
-		relPath, err := filepath.Rel(localDirPath, path)
-		if err != nil {
-			return fmt.Errorf("计算相对路径失败: %v", err)
-		}
-
-		// 检查文件是否被排除
-		if shouldExclude(relPath, options) || shouldExclude(absPath, options) {
-			excludeCount++
-			return nil
-		}
-
-		// 在Windows系统上将反斜杠转换为正斜杠
-		relPath = filepath.ToSlash(relPath)
-
 		// 构建OSS上的完整路径
-		ossObjectPath := ossDirPath + relPath
-
-		// 如果是增量上传，检查文件是否需要上传
-		if options != nil && options.Incremental {
-			needUpload, err := c.needUpload(path, ossObjectPath)
-			if err != nil {
-				return fmt.Errorf("检查文件是否需要上传失败: %v", err)
-			}
-			if !needUpload {
-				skipCount++
-				return nil
-			}
-		}
+		ossObjectPath := ossDirPath + file.relPath
+
+		scheduler.submit(&transferTask{
+			name: file.path,
+			size: file.info.Size(),
+			run: func(ctx context.Context) (string, error) {
+				// 如果是增量上传，检查文件是否需要上传
+				if options != nil && options.Incremental {
+					needUpload, err := c.needUploadLocal(file, ossObjectPath)
+					if err != nil {
+						return "", err
+					}
+					if !needUpload {
+						return "", options.Manifest.addLocal(file, ossObjectPath)
+					}
+				}
+				return c.putLocalFileIfExists(ctx, file, ossObjectPath, options, conflicts)
+			},
+		})
+		return nil
+	})
+	result := scheduler.wait()
 
-		// 上传文件
-		ossOptions := []oss.Option{
-			oss.ContentDisposition(fmt.Sprintf("attachment; filename=\"%s\"", filepath.Base(path))),
-		}
+	if walkErr != nil && ctx.Err() == nil {
+		conflicts.printSummary()
+		return fmt.Errorf("上传目录失败: %v", walkErr)
+	}
 
-		err = c.bucket.PutObjectFromFile(ossObjectPath, path, ossOptions...)
-		if err != nil {
-			return fmt.Errorf("上传文件 %s 失败: %v", path, err)
+	// 如果没有文件需要上传
+	if result.Total == 0 {
+		fmt.Printf("没有文件需要上传到 %s\n", ossDirPath)
+		if excludeCount > 0 {
+			fmt.Printf("已排除 %d 个文件\n", excludeCount)
 		}
-
-		uploadCount++
-		fmt.Printf("已上传: %s\n", ossObjectPath)
-
 		return nil
-	})
-
-	if err != nil {
-		return fmt.Errorf("上传目录失败: %v", err)
 	}
 
-	fmt.Printf("成功上传 %d 个文件到 %s", uploadCount, ossDirPath)
+	printTransferResult("上传", result)
 	if excludeCount > 0 {
-		fmt.Printf("，已排除 %d 个文件", excludeCount)
-	}
-	if skipCount > 0 {
-		fmt.Printf("，已跳过 %d 个无变化文件", skipCount)
+		fmt.Printf("，%d 个文件被排除", excludeCount)
 	}
 	fmt.Println()
-	return nil
+	conflicts.printSummary()
+
+	return result.err("上传")
 }
 
-// concurrentUploadDirectory 并发上传目录中的文件
-func (c *OSSClient) concurrentUploadDirectory(localDirPath, ossDirPath string, options *UploadOptions, workerCount int) error {
-	// 文件扫描阶段
-	var tasks []*uploadTask
-	var excludeCount int
+// uploadRetries 获取上传失败时的重试次数
+func uploadRetries(options *UploadOptions) int {
+	if options == nil {
+		return 0
+	}
+	return options.Retries
+}
 
-	// 使用互斥锁保护共享变量
-	var mu sync.Mutex
+// walkLocalDir 遍历本地目录，对每个未被排除的文件调用fn，返回被排除的文件数
+// 符号链接按options.Symlinks处理，跟随目录链接时会检测循环
+func walkLocalDir(root string, options *UploadOptions, fn func(file *localFile) error) (int, error) {
+	symlinks := SymlinkFollow
+	if options != nil && options.Symlinks != "" {
+		symlinks = options.Symlinks
+	}
 
-	fmt.Println("正在扫描文件...")
+	excludeCount := 0
+	// 当前遍历路径上的真实目录，用于检测链接循环
+	ancestors := make(map[string]bool)
 
-	err := filepath.Walk(localDirPath, func(path string, info os.FileInfo, err error) error {
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		realDir, err := filepath.EvalSymlinks(dir)
 		if err != nil {
 			return err
 		}
-
-		// 跳过目录本身
-		if info.IsDir() {
+		if ancestors[realDir] {
+			fmt.Printf("跳过(循环链接): %s\n", dir)
 			return nil
 		}
+		ancestors[realDir] = true
+		defer delete(ancestors, realDir)
 
-		// 获取文件的绝对路径
-		absPath, err := filepath.Abs(path)
-		if err != nil {
-			return fmt.Errorf("获取绝对路径失败: %v", err)
-		}
-
-		// 计算相对路径
-		relPath, err := filepath.Rel(localDirPath, path)
+		entries, err := os.ReadDir(dir)
 		if err != nil {
-			return fmt.Errorf("计算相对路径失败: %v", err)
-		}
-
-		// 检查文件是否被排除
-		if shouldExclude(relPath, options) || shouldExclude(absPath, options) {
-			mu.Lock()
-			excludeCount++
-			mu.Unlock()
-			return nil
-		}
-
-		// 在Windows系统上将反斜杠转换为正斜杠
-		relPath = filepath.ToSlash(relPath)
-
-		// 构建OSS上的完整路径
-		ossObjectPath := ossDirPath + relPath
-
-		// 创建上传任务
-		task := &uploadTask{
-			localPath:     path,
-			ossPath:       ossObjectPath,
-			needUpload:    true,
-			needCheckHash: options != nil && options.Incremental,
+			return err
 		}
 
-		// 将任务添加到队列
-		mu.Lock()
-		tasks = append(tasks, task)
-		mu.Unlock()
-
-		return nil
-	})
-
-	if err != nil {
-		return fmt.Errorf("扫描目录失败: %v", err)
-	}
-
-	// 如果没有文件需要上传
-	if len(tasks) == 0 {
-		fmt.Printf("没有文件需要上传到 %s\n", ossDirPath)
-		if excludeCount > 0 {
-			fmt.Printf("已排除 %d 个文件\n", excludeCount)
-		}
-		return nil
-	}
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
 
-	fmt.Printf("共扫描到 %d 个文件需要处理\n", len(tasks))
+			info, err := os.Lstat(path)
+			if err != nil {
+				return err
+			}
 
-	// 检查哈希阶段
-	if options != nil && options.Incremental {
-		fmt.Println("正在检查文件是否需要上传...")
-
-		// 使用单独的通道进行哈希检查
-		hashChan := make(chan *uploadTask, len(tasks))
-		hashDoneChan := make(chan bool, len(tasks))
-
-		// 启动哈希检查协程
-		var hashWg sync.WaitGroup
-		for i := 0; i < workerCount; i++ {
-			hashWg.Add(1)
-			go func(id int) {
-				defer hashWg.Done()
-				for task := range hashChan {
-					needUpload, err := c.needUpload(task.localPath, task.ossPath)
+			linkTarget := ""
+			if info.Mode()&os.ModeSymlink != 0 {
+				switch symlinks {
+				case SymlinkSkip:
+					continue
+				case SymlinkStore:
+					linkTarget, err = os.Readlink(path)
 					if err != nil {
-						task.err = fmt.Errorf("检查文件哈希失败: %v", err)
-						fmt.Printf("协程[%d] 检查错误: %s - %v\n", id, task.ossPath, err)
-					} else {
-						task.needUpload = needUpload
-						if !needUpload {
-							fmt.Printf("协程[%d] 跳过(无变化): %s\n", id, task.ossPath)
-						}
+						return fmt.Errorf("读取符号链接失败: %v", err)
+					}
+				default:
+					info, err = os.Stat(path)
+					if err != nil {
+						fmt.Printf("跳过(无效链接): %s\n", path)
+						continue
 					}
-					hashDoneChan <- true
 				}
-			}(i)
-		}
-
-		// 发送所有任务进行哈希检查
-		for _, task := range tasks {
-			if task.needCheckHash {
-				hashChan <- task
-			}
-		}
-		close(hashChan)
-
-		// 等待所有哈希检查完成
-		for range tasks {
-			// 只关心接收到信号，不使用具体值
-			<-hashDoneChan
-		}
-
-		hashWg.Wait()
-		close(hashDoneChan)
-
-		// 计算需要上传的文件数
-		var needUploadCount int
-		for _, task := range tasks {
-			if task.needUpload {
-				needUploadCount++
 			}
-		}
-
-		fmt.Printf("需要上传 %d 个文件，跳过 %d 个未变更文件\n",
-			needUploadCount, len(tasks)-needUploadCount)
-
-		// 如果没有文件需要上传，直接返回
-		if needUploadCount == 0 {
-			fmt.Println("所有文件都是最新的，无需上传")
-			return nil
-		}
-	}
-
-	// 上传文件阶段
-	fmt.Println("开始上传文件...")
-
-	// 创建上传通道和等待组
-	uploadChan := make(chan *uploadTask, len(tasks))
-	var uploadWg sync.WaitGroup
 
-	// 使用互斥锁保护输出
-	var outputMu sync.Mutex
-
-	// 启动上传协程
-	for i := 0; i < workerCount; i++ {
-		uploadWg.Add(1)
-		go func(id int) {
-			defer uploadWg.Done()
-			for task := range uploadChan {
-				if !task.needUpload {
+			if linkTarget == "" {
+				// 递归进入子目录
+				if info.IsDir() {
+					if err := walk(path); err != nil {
+						return err
+					}
 					continue
 				}
-
-				// 上传文件
-				ossOptions := []oss.Option{
-					oss.ContentDisposition(fmt.Sprintf("attachment; filename=\"%s\"", filepath.Base(task.localPath))),
+				// 跳过设备、管道等特殊文件
+				if !info.Mode().IsRegular() {
+					continue
 				}
+			}
 
-				err := c.bucket.PutObjectFromFile(task.ossPath, task.localPath, ossOptions...)
-
-				outputMu.Lock()
-				if err != nil {
-					task.err = fmt.Errorf("上传失败: %v", err)
-					fmt.Printf("协程[%d] 上传失败: %s - %v\n", id, task.ossPath, err)
-				} else {
-					fmt.Printf("协程[%d] 已上传: %s\n", id, task.ossPath)
-				}
-				outputMu.Unlock()
+			// 获取文件的绝对路径
+			absPath, err := filepath.Abs(path)
+			if err != nil {
+				return fmt.Errorf("获取绝对路径失败: %v", err)
 			}
-		}(i)
-	}
 
-	// 发送需要上传的任务
-	uploadCount := 0
-	for _, task := range tasks {
-		if task.needUpload {
-			uploadChan <- task
-			uploadCount++
-		}
-	}
+			// 计算相对路径
+			relPath, err := filepath.Rel(root, path)
+			if err != nil {
+				return fmt.Errorf("计算相对路径失败: %v", err)
+			}
 
-	// 关闭上传通道
-	close(uploadChan)
+			// 检查文件是否被排除
+			if shouldExclude(relPath, options) || shouldExclude(absPath, options) {
+				excludeCount++
+				continue
+			}
 
-	// 定期输出进度信息
-	doneChan := make(chan struct{})
-	go func() {
-		ticker := time.NewTicker(5 * time.Second)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ticker.C:
-				fmt.Println("上传中，请等待...")
-			case <-doneChan:
-				return
+			// 检查时间和大小条件
+			if options != nil && !options.Filter.match(info.ModTime(), info.Size()) {
+				excludeCount++
+				continue
 			}
-		}
-	}()
 
-	// 等待所有上传完成
-	uploadWg.Wait()
-	close(doneChan)
-
-	// 统计结果
-	var successCount, errorCount, skipCount int
-	for _, task := range tasks {
-		if task.err != nil {
-			errorCount++
-		} else if !task.needUpload {
-			skipCount++
-		} else {
-			successCount++
+			// 在Windows系统上将反斜杠转换为正斜杠
+			err = fn(&localFile{
+				path:       path,
+				relPath:    filepath.ToSlash(relPath),
+				info:       info,
+				linkTarget: linkTarget,
+			})
+			if err != nil {
+				return err
+			}
 		}
-	}
 
-	fmt.Printf("\n上传完成: %d 个文件成功", successCount)
-	if errorCount > 0 {
-		fmt.Printf(", %d 个文件失败", errorCount)
-	}
-	if excludeCount > 0 {
-		fmt.Printf(", %d 个文件被排除", excludeCount)
-	}
-	if skipCount > 0 {
-		fmt.Printf(", %d 个文件无变化被跳过", skipCount)
-	}
-	fmt.Println()
-
-	// 如果有错误，返回综合错误信息
-	if errorCount > 0 {
-		return fmt.Errorf("部分文件上传失败 (%d/%d)", errorCount, uploadCount)
+		return nil
 	}
 
-	return nil
+	return excludeCount, walk(root)
 }
 
 // shouldExclude 检查文件是否应该被排除
@@ -684,15 +715,45 @@ func shouldExclude(path string, options *UploadOptions) bool {
 }
 
 // DownloadFile 从OSS下载文件到本地
-func (c *OSSClient) DownloadFile(ossPath, localPath string, options *DownloadOptions) error {
+func (c *OSSClient) DownloadFile(ctx context.Context, ossPath, localPath string, options *DownloadOptions) error {
+	// 下载并解压压缩包
+	if options != nil && options.Extract {
+		return c.DownloadAndExtract(ctx, ossPath, localPath)
+	}
+
 	// 检查路径是否以斜杠结尾，可能是目录
 	if strings.HasSuffix(ossPath, "/") {
-		return c.DownloadDirectory(ossPath, localPath, options)
+		if options != nil && options.VersionID != "" {
+			return fmt.Errorf("下载目录时不支持指定版本")
+		}
+		return c.DownloadDirectory(ctx, ossPath, localPath, options)
 	}
 
 	// 标准化OSS路径，去除前导斜杠
 	ossPath = strings.TrimPrefix(ossPath, "/")
 
+	// 检查文件标签是否满足条件
+	if options != nil && len(options.TagFilters) > 0 {
+		tags, err := c.GetTags(ossPath)
+		if err != nil {
+			return err
+		}
+		if !matchTags(tags, options.TagFilters) {
+			return fmt.Errorf("文件标签不满足条件: %s", ossPath)
+		}
+	}
+
+	// 检查时间和大小条件
+	if options != nil && options.VersionID == "" {
+		matched, err := c.objectMatchesFilter(ossPath, options.Filter)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			return fmt.Errorf("文件不满足筛选条件: %s", ossPath)
+		}
+	}
+
 	// 如果本地路径是目录，则使用OSS文件名
 	fileInfo, err := os.Stat(localPath)
 	if err == nil && fileInfo.IsDir() {
@@ -705,7 +766,28 @@ func (c *OSSClient) DownloadFile(ossPath, localPath string, options *DownloadOpt
 		return fmt.Errorf("创建本地目录失败: %v", err)
 	}
 
-	err = c.bucket.GetObjectToFile(ossPath, localPath)
+	var ossOptions []oss.Option
+	if options != nil && options.VersionID != "" {
+		ossOptions = append(ossOptions, oss.VersionId(options.VersionID))
+	}
+
+	conflicts := newConflictLog(downloadIfExists(options))
+	localPath, err = resolveLocalConflict(localPath, conflicts)
+	conflicts.printSummary()
+	if err != nil || localPath == "" {
+		return err
+	}
+
+	retries := 0
+	if options != nil {
+		retries = options.Retries
+	}
+	_, err = runTransfer(ctx, "下载", retries, &transferTask{
+		name: ossPath,
+		run: func(ctx context.Context) (string, error) {
+			return localPath, c.downloadObject(ctx, ossPath, localPath, ossOptions...)
+		},
+	})
 	if err != nil {
 		return fmt.Errorf("下载文件失败: %v", err)
 	}
@@ -713,209 +795,282 @@ func (c *OSSClient) DownloadFile(ossPath, localPath string, options *DownloadOpt
 	return nil
 }
 
-// DownloadDirectory 从OSS下载目录到本地
-func (c *OSSClient) DownloadDirectory(ossPrefix, localPath string, options *DownloadOptions) error {
-	// 标准化OSS路径，去除前导斜杠
-	ossPrefix = strings.TrimPrefix(ossPrefix, "/")
-
-	// 如果OSS前缀不以斜杠结尾，添加斜杠
-	if !strings.HasSuffix(ossPrefix, "/") {
-		ossPrefix += "/"
+// downloadIfExists 获取下载的冲突策略
+func downloadIfExists(options *DownloadOptions) string {
+	if options == nil {
+		return ""
 	}
+	return options.IfExists
+}
 
-	// 确保本地目录存在
-	if err := os.MkdirAll(localPath, 0755); err != nil {
-		return fmt.Errorf("创建本地目录失败: %v", err)
+// downloadObject 下载OSS文件到本地，并根据对象元数据恢复文件属性
+// 链接对象会被还原为符号链接
+func (c *OSSClient) downloadObject(ctx context.Context, ossPath, localPath string, ossOptions ...oss.Option) (err error) {
+	var size int64
+	defer func() {
+		c.stats.addContext(ctx, AuditEntry{Op: "get", Bucket: c.config.Bucket, Keys: []string{ossPath}, Dest: localPath, Bytes: size}, err)
+	}()
+
+	ossOptions = append(ossOptions, oss.WithContext(ctx))
+	result, err := c.bucket.DoGetObject(&oss.GetObjectRequest{ObjectKey: ossPath}, ossOptions)
+	if err != nil {
+		return err
 	}
+	defer result.Response.Close()
 
-	// 列出指定前缀的所有文件
-	fmt.Printf("列出OSS目录: %s\n", ossPrefix)
-	files, err := c.ListFiles(ossPrefix)
+	// 先写入临时文件，下载完成后再重命名，中断时删除
+	tempPath := localPath + oss.TempFileSuffix
+	untrack := trackPartial(tempPath)
+	defer untrack()
+	fd, err := os.OpenFile(tempPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	size, err = io.Copy(fd, result.Response.Body)
+	fd.Close()
 	if err != nil {
-		return fmt.Errorf("列举文件失败: %v", err)
+		os.Remove(tempPath)
+		return err
 	}
 
-	if len(files) == 0 {
-		return fmt.Errorf("目录为空或不存在: %s", ossPrefix)
+	// 校验CRC
+	if c.bucket.GetConfig().IsEnableCRC && result.ClientCRC != nil {
+		result.Response.ClientCRC = result.ClientCRC.Sum64()
+		if err := oss.CheckCRC(result.Response, "GetObjectToFile"); err != nil {
+			os.Remove(tempPath)
+			return err
+		}
 	}
 
-	fmt.Printf("找到 %d 个文件，开始下载...\n", len(files))
+	header := result.Response.Headers
+	if header.Get(oss.HTTPHeaderOssMetaPrefix+metaSymlink) != "" {
+		// 链接对象的内容为链接目标
+		target, err := os.ReadFile(tempPath)
+		os.Remove(tempPath)
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(localPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return os.Symlink(string(target), localPath)
+	}
 
-	// 如果启用并发下载
-	if options != nil && options.Concurrent {
-		return c.concurrentDownloadFiles(files, ossPrefix, localPath, options.WorkerCount)
+	if err := os.Rename(tempPath, localPath); err != nil {
+		return err
 	}
 
-	// 顺序下载
-	for i, ossFile := range files {
-		// 计算相对路径
-		relPath := strings.TrimPrefix(ossFile, ossPrefix)
-		if relPath == "" {
-			continue // 跳过目录本身
-		}
+	return applyFileAttrs(localPath, header)
+}
 
-		// 构建本地文件路径
-		localFile := filepath.Join(localPath, filepath.FromSlash(relPath))
+// prepareLocalPath 创建本地文件所在目录，并确认文件路径位于root目录内
+// 防止OSS路径中的".."或已下载的符号链接把文件写到目录外
+func prepareLocalPath(root, localFile string) error {
+	if !isInsideDir(root, localFile) {
+		return fmt.Errorf("路径超出目标目录: %s", localFile)
+	}
 
-		// 确保本地目录存在
-		localDir := filepath.Dir(localFile)
-		if err := os.MkdirAll(localDir, 0755); err != nil {
-			return fmt.Errorf("创建本地目录失败: %v", err)
-		}
+	return ensureLocalDir(root, filepath.Dir(localFile))
+}
 
-		// 下载文件
-		err := c.bucket.GetObjectToFile(ossFile, localFile)
-		if err != nil {
-			return fmt.Errorf("下载文件失败: %v", err)
-		}
+// ensureLocalDir 创建root目录下的子目录，并确认解析符号链接后仍位于root目录内
+func ensureLocalDir(root, dir string) error {
+	if !isInsideDir(root, dir) {
+		return fmt.Errorf("路径超出目标目录: %s", dir)
+	}
 
-		fmt.Printf("[%d/%d] 已下载: %s\n", i+1, len(files), relPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("创建本地目录失败: %v", err)
+	}
+
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return err
+	}
+	realDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return err
+	}
+	if !isInsideDir(realRoot, realDir) {
+		return fmt.Errorf("路径通过符号链接指向目标目录外: %s", dir)
 	}
 
-	fmt.Printf("成功下载 %d 个文件到 %s\n", len(files), localPath)
 	return nil
 }
 
-// concurrentDownloadFiles 并发下载多个文件
-func (c *OSSClient) concurrentDownloadFiles(files []string, ossPrefix, localPath string, workerCount int) error {
-	if workerCount <= 0 {
-		workerCount = 10 // 默认10个并发
+// isInsideDir 判断path是否位于root目录内（仅按路径判断，不解析符号链接）
+func isInsideDir(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// DownloadDirectory 从OSS下载目录到本地
+func (c *OSSClient) DownloadDirectory(ctx context.Context, ossPrefix, localPath string, options *DownloadOptions) error {
+	// 标准化OSS路径，去除前导斜杠
+	ossPrefix = strings.TrimPrefix(ossPrefix, "/")
+
+	// 如果OSS前缀不以斜杠结尾，添加斜杠
+	if !strings.HasSuffix(ossPrefix, "/") {
+		ossPrefix += "/"
 	}
 
-	fmt.Printf("使用并发下载模式 (工作协程数: %d)\n", workerCount)
+	// 确保本地目录存在
+	if err := os.MkdirAll(localPath, 0755); err != nil {
+		return fmt.Errorf("创建本地目录失败: %v", err)
+	}
 
-	// 创建下载任务
-	type downloadTask struct {
-		ossFile   string
-		localFile string
-		relPath   string
-		err       error
+	// 未启用并发下载时按顺序逐个下载
+	workerCount, retries, listParallel := 1, 0, 1
+	var filter *TransferFilter
+	var tagFilters []TagFilter
+	if options != nil {
+		retries = options.Retries
+		filter = options.Filter
+		tagFilters = options.TagFilters
+		listParallel = options.ListParallel
+		if options.Concurrent {
+			workerCount = options.WorkerCount
+			if workerCount <= 0 {
+				workerCount = 10 // 默认10个并发
+			}
+			fmt.Printf("使用并发下载模式 (工作协程数: %d)\n", workerCount)
+		}
 	}
 
-	var tasks []*downloadTask
-	for _, ossFile := range files {
+	// 边列举边下载，不等待列举完成
+	fmt.Printf("列出OSS目录: %s\n", ossPrefix)
+	conflicts := newConflictLog(downloadIfExists(options))
+	scheduler := newTransferScheduler(ctx, "下载", workerCount, retries, nil)
+	listed := 0
+	var tagMismatch atomic.Int64
+	listErr := c.WalkObjects(ctx, ossPrefix, listParallel, func(object oss.ObjectProperties) error {
+		if !filter.matchObject(object) {
+			return nil
+		}
+		ossFile := object.Key
+
 		// 计算相对路径
 		relPath := strings.TrimPrefix(ossFile, ossPrefix)
 		if relPath == "" {
-			continue // 跳过目录本身
+			return nil // 跳过目录本身
 		}
+		listed++
 
 		// 构建本地文件路径
 		localFile := filepath.Join(localPath, filepath.FromSlash(relPath))
 
-		tasks = append(tasks, &downloadTask{
-			ossFile:   ossFile,
-			localFile: localFile,
-			relPath:   relPath,
-		})
-	}
-
-	// 创建下载通道和等待组
-	downloadChan := make(chan *downloadTask, len(tasks))
-	var downloadWg sync.WaitGroup
-
-	// 使用互斥锁保护输出
-	var outputMu sync.Mutex
-
-	// 启动下载协程
-	for i := 0; i < workerCount; i++ {
-		downloadWg.Add(1)
-		go func(id int) {
-			defer downloadWg.Done()
-			for task := range downloadChan {
-				// 确保本地目录存在
-				localDir := filepath.Dir(task.localFile)
-				if err := os.MkdirAll(localDir, 0755); err != nil {
-					task.err = fmt.Errorf("创建本地目录失败: %v", err)
-					outputMu.Lock()
-					fmt.Printf("协程[%d] 错误: %s - %v\n", id, task.relPath, err)
-					outputMu.Unlock()
-					continue
+		// 重试时使用第一次确定的保存路径
+		target := ""
+		scheduler.submit(&transferTask{
+			name: ossFile,
+			size: object.Size,
+			run: func(ctx context.Context) (string, error) {
+				if target == "" {
+					// 按标签筛选文件，在下载任务中检查，不需要等待列举完成
+					if len(tagFilters) > 0 {
+						tags, err := c.GetTags(ossFile)
+						if err != nil {
+							return "", err
+						}
+						if !matchTags(tags, tagFilters) {
+							tagMismatch.Add(1)
+							return "", nil
+						}
+					}
+					// 确保本地目录存在
+					if err := prepareLocalPath(localPath, localFile); err != nil {
+						return "", err
+					}
+					// 处理本地已存在的文件
+					resolved, err := resolveLocalConflict(localFile, conflicts)
+					if err != nil || resolved == "" {
+						return "", err
+					}
+					target = resolved
 				}
-
-				// 下载文件
-				err := c.bucket.GetObjectToFile(task.ossFile, task.localFile)
-
-				outputMu.Lock()
-				if err != nil {
-					task.err = fmt.Errorf("下载失败: %v", err)
-					fmt.Printf("协程[%d] 下载失败: %s - %v\n", id, task.relPath, err)
-				} else {
-					fmt.Printf("协程[%d] 已下载: %s\n", id, task.relPath)
+				if err := c.downloadObject(ctx, ossFile, target); err != nil {
+					return "", err
 				}
-				outputMu.Unlock()
-			}
-		}(i)
-	}
+				return target, nil
+			},
+		})
+		return nil
+	})
+	result := scheduler.wait()
 
-	// 发送下载任务
-	for _, task := range tasks {
-		downloadChan <- task
+	if listErr != nil && ctx.Err() == nil {
+		return fmt.Errorf("%v（已下载 %d 个文件）", listErr, result.Done)
 	}
-
-	// 关闭下载通道
-	close(downloadChan)
-
-	// 定期输出进度信息
-	doneChan := make(chan struct{})
-	go func() {
-		ticker := time.NewTicker(5 * time.Second)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ticker.C:
-				fmt.Println("下载中，请等待...")
-			case <-doneChan:
-				return
-			}
+	if listed == 0 && ctx.Err() == nil {
+		if !filter.empty() {
+			return fmt.Errorf("没有满足筛选条件的文件: %s", ossPrefix)
 		}
-	}()
+		return fmt.Errorf("目录为空或不存在: %s", ossPrefix)
+	}
+	if n := int(tagMismatch.Load()); n > 0 && n == listed {
+		return fmt.Errorf("没有标签满足条件的文件: %s", ossPrefix)
+	}
 
-	// 等待所有下载完成
-	downloadWg.Wait()
-	close(doneChan)
+	printTransferResult("下载", result)
+	if n := tagMismatch.Load(); n > 0 {
+		fmt.Printf("（其中 %d 个文件标签不满足条件）", n)
+	}
+	fmt.Println()
+	conflicts.printSummary()
 
-	// 统计结果
-	var successCount, errorCount int
-	for _, task := range tasks {
-		if task.err != nil {
-			errorCount++
-		} else {
-			successCount++
-		}
+	return result.err("下载")
+}
+
+// ListFiles 列出指定前缀的文件
+func (c *OSSClient) ListFiles(prefix string) ([]string, error) {
+	objects, err := c.ListObjects(prefix)
+	if err != nil {
+		return nil, err
 	}
 
-	fmt.Printf("\n下载完成: %d 个文件成功", successCount)
-	if errorCount > 0 {
-		fmt.Printf(", %d 个文件失败", errorCount)
+	files := make([]string, 0, len(objects))
+	for _, object := range objects {
+		files = append(files, object.Key)
 	}
-	fmt.Println()
 
-	// 如果有错误，返回综合错误信息
-	if errorCount > 0 {
-		return fmt.Errorf("部分文件下载失败 (%d/%d)", errorCount, len(tasks))
+	return files, nil
+}
+
+// ListObjects 列出指定前缀的文件及其大小、ETag和修改时间
+func (c *OSSClient) ListObjects(prefix string) ([]oss.ObjectProperties, error) {
+	var objects []oss.ObjectProperties
+	err := c.WalkObjects(context.Background(), prefix, 1, func(object oss.ObjectProperties) error {
+		objects = append(objects, object)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return objects, nil
 }
 
-// ListFiles 列出指定前缀的文件
-func (c *OSSClient) ListFiles(prefix string) ([]string, error) {
+// ListDir 按目录方式列出前缀下的一层内容，返回子目录前缀和文件
+func (c *OSSClient) ListDir(prefix string) ([]string, []oss.ObjectProperties, error) {
 	// 标准化前缀，去除前导斜杠
 	prefix = strings.TrimPrefix(prefix, "/")
 
 	marker := ""
-	var files []string
+	var dirs []string
+	var objects []oss.ObjectProperties
 
 	for {
-		lsRes, err := c.bucket.ListObjects(oss.Marker(marker), oss.Prefix(prefix))
+		lsRes, err := c.bucket.ListObjects(oss.Marker(marker), oss.Prefix(prefix), oss.Delimiter("/"))
 		if err != nil {
-			return nil, fmt.Errorf("列举文件失败: %v", err)
+			return nil, nil, fmt.Errorf("列举文件失败: %v", err)
 		}
 
+		dirs = append(dirs, lsRes.CommonPrefixes...)
 		for _, object := range lsRes.Objects {
-			files = append(files, object.Key)
+			// 跳过目录占位对象本身
+			if object.Key != prefix {
+				objects = append(objects, object)
+			}
 		}
 
 		if lsRes.IsTruncated {
@@ -925,7 +1080,7 @@ func (c *OSSClient) ListFiles(prefix string) ([]string, error) {
 		}
 	}
 
-	return files, nil
+	return dirs, objects, nil
 }
 
 // GetSignedURL 获取文件的临时访问URL
@@ -942,17 +1097,18 @@ func (c *OSSClient) GetSignedURL(ossPath string, expireTime time.Duration) (stri
 	return signedURL, nil
 }
 
-// DeleteFile 删除OSS上的文件
-func (c *OSSClient) DeleteFile(ossPath string) error {
+// DeleteFile 删除OSS上的文件，删除目录时listParallel为并行列举的协程数
+func (c *OSSClient) DeleteFile(ctx context.Context, ossPath string, listParallel int) error {
 	// 标准化OSS路径，去除前导斜杠
 	ossPath = strings.TrimPrefix(ossPath, "/")
 
 	// 检查路径是否以斜杠结尾，如果是，则可能是要删除文件夹
 	if strings.HasSuffix(ossPath, "/") || strings.Contains(ossPath, "*") {
-		return c.DeleteDirectory(ossPath)
+		return c.DeleteDirectory(ctx, ossPath, listParallel)
 	}
 
 	err := c.bucket.DeleteObject(ossPath)
+	c.audit(AuditEntry{Op: AuditDelete, Keys: []string{ossPath}}, err)
 	if err != nil {
 		return fmt.Errorf("删除文件失败: %v", err)
 	}
@@ -961,7 +1117,8 @@ func (c *OSSClient) DeleteFile(ossPath string) error {
 }
 
 // DeleteDirectory 删除OSS上的目录（删除指定前缀的所有文件）
-func (c *OSSClient) DeleteDirectory(prefix string) error {
+// 边列举边删除，每列出1000个文件就批量删除一次，不等待列举完成
+func (c *OSSClient) DeleteDirectory(ctx context.Context, prefix string, listParallel int) error {
 	// 标准化OSS路径，去除前导斜杠
 	prefix = strings.TrimPrefix(prefix, "/")
 
@@ -970,20 +1127,69 @@ func (c *OSSClient) DeleteDirectory(prefix string) error {
 		prefix += "/"
 	}
 
-	// 获取所有匹配前缀的文件
-	files, err := c.ListFiles(prefix)
-	if err != nil {
-		return fmt.Errorf("获取文件列表失败: %v", err)
+	// 删除在单独的协程中进行，列举同时继续；删除出错后停止列举
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	batchChan := make(chan []string, 1)
+	deleted := 0
+	var deleteErr error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for keys := range batchChan {
+			if deleteErr != nil {
+				continue
+			}
+			_, err := c.bucket.DeleteObjects(keys, oss.DeleteObjectsQuiet(true))
+			c.audit(AuditEntry{Op: AuditDelete, Keys: keys}, err)
+			if err != nil {
+				deleteErr = fmt.Errorf("批量删除失败（已删除 %d 个文件）: %v", deleted, err)
+				cancel()
+				continue
+			}
+			deleted += len(keys)
+			fmt.Printf("已删除 %d 个文件\n", deleted)
+		}
+	}()
+
+	var batch []string
+	listErr := c.WalkObjects(ctx, prefix, listParallel, func(object oss.ObjectProperties) error {
+		batch = append(batch, object.Key)
+		if len(batch) == deleteBatchSize {
+			batchChan <- batch
+			batch = nil
+		}
+		return nil
+	})
+	if listErr == nil && len(batch) > 0 {
+		batchChan <- batch
 	}
+	close(batchChan)
+	<-done
 
-	if len(files) == 0 {
+	if deleteErr != nil {
+		return deleteErr
+	}
+	if listErr != nil {
+		if errors.Is(listErr, context.Canceled) {
+			return fmt.Errorf("操作已中断: 已删除 %d 个文件", deleted)
+		}
+		return fmt.Errorf("%v（已删除 %d 个文件）", listErr, deleted)
+	}
+	if deleted == 0 {
 		return fmt.Errorf("未找到匹配的文件")
 	}
 
-	// 批量删除文件
+	fmt.Printf("成功删除 %d 个文件\n", deleted)
+	return nil
+}
+
+// deleteObjects 逐个删除文件
+func (c *OSSClient) deleteObjects(files []string) error {
 	deleteCount := 0
 	for _, file := range files {
 		err := c.bucket.DeleteObject(file)
+		c.audit(AuditEntry{Op: AuditDelete, Keys: []string{file}}, err)
 		if err != nil {
 			return fmt.Errorf("删除文件 %s 失败: %v", file, err)
 		}
@@ -997,197 +1203,556 @@ func (c *OSSClient) DeleteDirectory(prefix string) error {
 
 func printUsage() {
 	fmt.Println("阿里云OSS工具使用方法:")
-	fmt.Println("全局选项:")
-	fmt.Println("  -f <配置文件路径>        指定配置文件路径，默认为~/.oss-config")
+	fmt.Println("用法: alioss <命令> [参数] [选项]")
 	fmt.Println("")
 	fmt.Println("命令:")
-	fmt.Println("  上传文件/文件夹: alioss upload <本地文件或文件夹路径> [OSS路径] [--exclude 模式1,模式2,...] [--incremental] [--concurrent [--workers 数量]]")
-	fmt.Println("  下载文件/文件夹: alioss download <OSS路径> <本地保存路径> [--concurrent [--workers 数量]]")
-	fmt.Println("  列出文件: alioss list [前缀]")
-	fmt.Println("  删除文件/文件夹: alioss delete <OSS路径或前缀>")
-	fmt.Println("  获取临时URL: alioss url <OSS路径> [过期时间(秒)，默认3600]")
+	width := 0
+	for _, spec := range commands {
+		if w := displayWidth(spec.name); w > width {
+			width = w
+		}
+	}
+	indent := strings.Repeat(" ", width+4)
+	for _, spec := range commands {
+		fmt.Printf("  %s%s  %s\n", spec.name, strings.Repeat(" ", width-displayWidth(spec.name)), spec.summary)
+		if spec.usage != "" {
+			fmt.Printf("%salioss %s %s\n", indent, spec.name, spec.usage)
+		}
+	}
+	fmt.Println("")
+	fmt.Println("全局选项:")
+	printFlags(globalFlags)
+	fmt.Println("")
+	fmt.Println("筛选选项（用于upload、download、list、delete）:")
+	printFlags(filterFlags)
+	fmt.Println("")
+	fmt.Println("选项可以放在命令后的任意位置，使用 alioss help <命令> 或 alioss <命令> --help 查看命令的全部选项")
 }
 
 func main() {
-	if len(os.Args) < 2 {
-		printUsage()
+	// 补全脚本调用的内部命令，命令行可能不完整，不做检查
+	if len(os.Args) > 1 && os.Args[1] == completeCommandName {
+		completeCommandLine(os.Args[2:])
 		return
 	}
 
-	// 解析全局选项
-	clientOptions := &ClientOptions{}
-
-	// 查找 -f 选项
-	for i := 1; i < len(os.Args); i++ {
-		if os.Args[i] == "-f" && i+1 < len(os.Args) {
-			clientOptions.ConfigFile = os.Args[i+1]
+	p, err := parseCommandLine(os.Args[1:])
+	if err != nil {
+		usageError(p.spec, err)
+	}
+	if p.spec == nil {
+		printUsage()
+		return
+	}
+	if p.help {
+		printCommandHelp(p.spec)
+		return
+	}
 
-			// 移除这两个参数，使后面的命令解析更简单
-			if i+2 < len(os.Args) {
-				os.Args = append(os.Args[:i], os.Args[i+2:]...)
-			} else {
-				os.Args = os.Args[:i]
-			}
-			break
-		}
+	// 解析全局选项，未指定 -p 时使用环境变量ALIOSS_PROFILE
+	clientOptions := &ClientOptions{
+		ConfigFile: p.str("config", ""),
+		Profile:    p.str("profile", os.Getenv("ALIOSS_PROFILE")),
 	}
 
-	// 如果参数被移除后没有足够的参数，则显示帮助
-	if len(os.Args) < 2 {
-		printUsage()
+	command := p.spec.name
+	switch command {
+	case "help":
+		if p.arg(0) == "" {
+			printUsage()
+			return
+		}
+		spec := findCommand(p.arg(0))
+		if spec == nil {
+			usageError(nil, fmt.Errorf("未知命令: %s", p.arg(0)))
+		}
+		printCommandHelp(spec)
+		return
+	case "completion":
+		printCompletionScript(p.arg(0))
+		return
+	case "gui":
+		if err := guiCommand(clientOptions, p.has("demo")); err != nil {
+			fmt.Fprintf(os.Stderr, "图形界面失败: %v\n", err)
+			exit(1)
+		}
 		return
 	}
+	hookOptions := &HookOptions{
+		OnComplete: p.str("on-complete", ""),
+		Webhook:    p.str("webhook", ""),
+		Disable:    p.has("no-hooks"),
+	}
 
 	client, err := NewOSSClient(clientOptions)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
-		os.Exit(1)
+		exit(1)
 	}
 
-	command := os.Args[1]
+	client.auditLog.command = command
+
+	// 命令完成或出错退出时运行钩子
+	if hooks := client.newCommandHooks(command, p.args, hookOptions); hooks != nil {
+		onExit = hooks.run
+		defer hooks.run(0)
+	}
+
+	// 收到中断信号时停止进行中的传输，交互模式、监听和Web服务自行处理信号
+	ctx := context.Background()
+	switch command {
+	case "shell", "watch", "serve":
+	default:
+		var stop func()
+		ctx, stop = withInterrupt(ctx)
+		defer stop()
+	}
 
 	switch command {
 	case "upload":
-		if len(os.Args) < 3 {
-			fmt.Println("错误: 缺少本地文件路径")
-			printUsage()
-			os.Exit(1)
-		}
-		localPath := os.Args[2]
-		ossPath := ""
-		if len(os.Args) > 3 && !strings.HasPrefix(os.Args[3], "--") {
-			ossPath = os.Args[3]
-		}
+		localPath := p.arg(0)
+		ossPath := p.arg(1)
 
 		// 处理选项
 		uploadOptions := &UploadOptions{
-			WorkerCount: 10, // 默认10个工作协程
+			WorkerCount: p.int("workers", 10), // 默认10个工作协程
+			Incremental: p.has("incremental"),
+			Concurrent:  p.has("concurrent"),
+			Symlinks:    p.str("symlinks", ""),
+			Archive:     p.str("archive", ""),
+			Template:    p.str("template", ""),
+			PartSize:    int64(p.int("part-size", 0)) * 1024 * 1024,
+			Retries:     flagRetries(p),
+			IfExists:    p.str("if-exists", ""),
 		}
-
-		for i := 3; i < len(os.Args); i++ {
-			// 处理排除选项
-			if os.Args[i] == "--exclude" && i+1 < len(os.Args) {
-				excludePatterns := strings.Split(os.Args[i+1], ",")
-				for j, pattern := range excludePatterns {
-					excludePatterns[j] = strings.TrimSpace(pattern)
-				}
-				uploadOptions.ExcludePatterns = excludePatterns
-				i++
+		if p.has("exclude") {
+			uploadOptions.ExcludePatterns = splitPatterns(p.str("exclude", ""))
+		}
+		if uploadOptions.Filter, err = parseFilterFlags(p); err != nil {
+			usageError(p.spec, err)
+		}
+		if uploadOptions.Tags, err = parseTags(p.list("tag")); err != nil {
+			usageError(p.spec, err)
+		}
+		if p.has("cas") {
+			uploadOptions.Template = casTemplate
+		}
+		mappingJSON := p.has("json")
+		signExpire := time.Duration(p.int("sign", 0)) * time.Second
+
+		// 生成SHA256清单，上传出错时也写入已上传的文件
+		manifestPath := p.str("manifest", "")
+		manifestKey := p.str("upload-manifest", "")
+		if manifestKey != "" && manifestPath == "" {
+			usageError(p.spec, fmt.Errorf("--upload-manifest 需要同时指定 --manifest"))
+		}
+		if manifestPath != "" && (localPath == "-" || uploadOptions.Archive != "") {
+			usageError(p.spec, fmt.Errorf("--manifest 不能用于从标准输入上传或打包上传"))
+		}
+		finishManifest := func(uploadErr error) {
+			if manifestPath == "" {
+				return
 			}
-			// 处理增量上传选项
-			if os.Args[i] == "--incremental" {
-				uploadOptions.Incremental = true
+			// 以JSON格式输出对应关系时，清单的提示输出到标准错误
+			var out io.Writer = os.Stdout
+			if mappingJSON {
+				out = os.Stderr
 			}
-			// 处理并发上传选项
-			if os.Args[i] == "--concurrent" {
-				uploadOptions.Concurrent = true
+			if err := client.finishUploadManifest(ctx, uploadOptions.Manifest, manifestPath, manifestKey, uploadErr, out); err != nil {
+				fmt.Fprintf(os.Stderr, "清单失败: %v\n", err)
+				exit(1)
 			}
-			// 处理工作协程数选项
-			if os.Args[i] == "--workers" && i+1 < len(os.Args) {
-				if _, err := fmt.Sscanf(os.Args[i+1], "%d", &uploadOptions.WorkerCount); err != nil {
-					fmt.Fprintf(os.Stderr, "警告: 无效的工作协程数，使用默认值\n")
+		}
+		if manifestPath != "" {
+			uploadOptions.Manifest = newUploadManifest()
+		}
+
+		// 本地路径为"-"时从标准输入读取
+		if localPath == "-" {
+			if err := client.UploadStream(ctx, os.Stdin, ossPath, uploadOptions); err != nil {
+				fmt.Fprintf(os.Stderr, "上传失败: %v\n", err)
+				exit(1)
+			}
+			fmt.Fprintln(os.Stderr, "上传完成!")
+			return
+		}
+
+		// 按路径模板上传，输出本地文件和OSS路径的对应关系
+		if uploadOptions.Template != "" {
+			if ossPath != "" {
+				usageError(p.spec, fmt.Errorf("使用路径模板时不需要指定OSS路径"))
+			}
+			results, err := client.UploadTemplate(ctx, localPath, uploadOptions.Template, uploadOptions, signExpire)
+			if results != nil {
+				if err := printUploadMapping(results, mappingJSON); err != nil {
+					fmt.Fprintf(os.Stderr, "输出结果失败: %v\n", err)
 				}
-				i++
 			}
+			finishManifest(err)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "上传失败: %v\n", err)
+				exit(1)
+			}
+			return
 		}
 
-		if err := client.UploadFile(localPath, ossPath, uploadOptions); err != nil {
+		// 打包为一个压缩文件上传
+		if uploadOptions.Archive != "" {
+			if err := client.UploadArchive(ctx, localPath, ossPath, uploadOptions); err != nil {
+				fmt.Fprintf(os.Stderr, "上传失败: %v\n", err)
+				exit(1)
+			}
+			fmt.Println("上传完成!")
+			return
+		}
+
+		err := client.UploadFile(ctx, localPath, ossPath, uploadOptions)
+		finishManifest(err)
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "上传失败: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
 		fmt.Println("上传完成!")
 
 	case "download":
-		if len(os.Args) < 4 {
-			fmt.Println("错误: 请提供OSS文件路径和本地保存路径")
-			printUsage()
-			os.Exit(1)
-		}
-		ossPath := os.Args[2]
-		localPath := os.Args[3]
+		ossPath := p.arg(0)
+		localPath := p.arg(1)
 
 		// 处理下载选项
 		downloadOptions := &DownloadOptions{
-			WorkerCount: 10, // 默认10个工作协程
+			WorkerCount:  p.int("workers", 10), // 默认10个工作协程
+			Concurrent:   p.has("concurrent"),
+			ListParallel: p.int("list-parallel", 1),
+			VersionID:    p.str("version-id", ""),
+			Extract:      p.has("extract"),
+			Retries:      flagRetries(p),
+			IfExists:     p.str("if-exists", ""),
 		}
-
-		for i := 4; i < len(os.Args); i++ {
-			// 处理并发下载选项
-			if os.Args[i] == "--concurrent" {
-				downloadOptions.Concurrent = true
-			}
-			// 处理工作协程数选项
-			if os.Args[i] == "--workers" && i+1 < len(os.Args) {
-				if _, err := fmt.Sscanf(os.Args[i+1], "%d", &downloadOptions.WorkerCount); err != nil {
-					fmt.Fprintf(os.Stderr, "警告: 无效的工作协程数，使用默认值\n")
-				}
-				i++
-			}
+		if downloadOptions.Filter, err = parseFilterFlags(p); err != nil {
+			usageError(p.spec, err)
+		}
+		if downloadOptions.TagFilters, err = parseTagFilters(p.list("tag")); err != nil {
+			usageError(p.spec, err)
 		}
 
-		if err := client.DownloadFile(ossPath, localPath, downloadOptions); err != nil {
+		if err := client.DownloadFile(ctx, ossPath, localPath, downloadOptions); err != nil {
 			fmt.Fprintf(os.Stderr, "下载失败: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
 		fmt.Println("下载完成!")
 
+	case "cat":
+		if err := client.CatFile(p.arg(0), os.Stdout, p.str("range", "")); err != nil {
+			fmt.Fprintf(os.Stderr, "读取失败: %v\n", err)
+			exit(1)
+		}
+
 	case "list":
-		prefix := ""
-		if len(os.Args) > 2 {
-			prefix = os.Args[2]
+		prefix := p.arg(0)
+		tagFilters, err := parseTagFilters(p.list("tag"))
+		if err != nil {
+			usageError(p.spec, err)
+		}
+		transferFilter, err := parseFilterFlags(p)
+		if err != nil {
+			usageError(p.spec, err)
+		}
+
+		if p.has("versions") {
+			// 历史版本的标签需要逐个版本获取，版本列表也不支持按子目录并行列举
+			for _, name := range []string{"tag", "list-parallel"} {
+				if p.has(name) {
+					usageError(p.spec, fmt.Errorf("--versions 不能与 --%s 同时使用", name))
+				}
+			}
+			versions, err := client.ListVersions(prefix)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "列举文件版本失败: %v\n", err)
+				exit(1)
+			}
+			printVersions(filterVersions(versions, transferFilter))
+			return
+		}
+
+		// 边列举边输出，按标签筛选时每攒够一批文件并发获取一次标签
+		count := 0
+		printKey := func(key string) {
+			if count == 0 {
+				fmt.Println("文件列表:")
+			}
+			count++
+			fmt.Println("  " + key)
+		}
+		var pending []string
+		flushPending := func() error {
+			if len(pending) == 0 {
+				return nil
+			}
+			matched, err := client.FilterByTags(pending, tagFilters, p.int("workers", 0))
+			if err != nil {
+				return fmt.Errorf("按标签筛选失败: %v", err)
+			}
+			for _, key := range matched {
+				printKey(key)
+			}
+			pending = pending[:0]
+			return nil
+		}
+		err = client.WalkObjects(ctx, prefix, p.int("list-parallel", 1), func(object oss.ObjectProperties) error {
+			if !transferFilter.matchObject(object) {
+				return nil
+			}
+			if len(tagFilters) == 0 {
+				printKey(object.Key)
+				return nil
+			}
+			pending = append(pending, object.Key)
+			if len(pending) >= tagFilterBatchSize {
+				return flushPending()
+			}
+			return nil
+		})
+		if err == nil {
+			err = flushPending()
 		}
-		files, err := client.ListFiles(prefix)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "列举文件失败: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
-		if len(files) == 0 {
+		if count == 0 {
 			fmt.Println("未找到文件")
-		} else {
-			fmt.Println("文件列表:")
-			for _, file := range files {
-				fmt.Println("  " + file)
-			}
 		}
 
 	case "delete":
-		if len(os.Args) < 3 {
-			fmt.Println("错误: 请提供OSS文件路径或前缀")
-			printUsage()
-			os.Exit(1)
+		ossPath := p.arg(0)
+		tagFilters, err := parseTagFilters(p.list("tag"))
+		if err != nil {
+			usageError(p.spec, err)
+		}
+		transferFilter, err := parseFilterFlags(p)
+		if err != nil {
+			usageError(p.spec, err)
+		}
+		useTrash := client.config.Trash
+		if p.has("trash") {
+			useTrash = true
+		}
+		if p.has("permanent") {
+			useTrash = false
+		}
+
+		if versionID := p.str("version-id", ""); versionID != "" {
+			if err := client.DeleteFileVersion(ossPath, versionID); err != nil {
+				fmt.Fprintf(os.Stderr, "删除失败: %v\n", err)
+				exit(1)
+			}
+			fmt.Println("文件版本删除成功!")
+			return
+		}
+		if useTrash {
+			if _, err := client.MoveToTrash(ossPath, tagFilters, transferFilter); err != nil {
+				fmt.Fprintf(os.Stderr, "删除失败: %v\n", err)
+				exit(1)
+			}
+			return
+		}
+		if len(tagFilters) > 0 || !transferFilter.empty() {
+			if err := client.DeleteFiltered(ossPath, tagFilters, transferFilter); err != nil {
+				fmt.Fprintf(os.Stderr, "删除失败: %v\n", err)
+				exit(1)
+			}
+			fmt.Println("文件删除成功!")
+			return
 		}
-		ossPath := os.Args[2]
-		if err := client.DeleteFile(ossPath); err != nil {
+		if err := client.DeleteFile(ctx, ossPath, p.int("list-parallel", 1)); err != nil {
 			fmt.Fprintf(os.Stderr, "删除失败: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
 		fmt.Println("文件删除成功!")
 
-	case "url":
-		if len(os.Args) < 3 {
-			fmt.Println("错误: 请提供OSS文件路径")
-			printUsage()
-			os.Exit(1)
+	case "undelete":
+		if err := client.Undelete(p.arg(0)); err != nil {
+			fmt.Fprintf(os.Stderr, "恢复失败: %v\n", err)
+			exit(1)
 		}
-		ossPath := os.Args[2]
+
+	case "url":
+		ossPath := p.arg(0)
 		expireTime := 3600 * time.Second // 默认1小时
-		if len(os.Args) > 3 {
-			expireSeconds := 0
-			if _, err := fmt.Sscanf(os.Args[3], "%d", &expireSeconds); err == nil && expireSeconds > 0 {
-				expireTime = time.Duration(expireSeconds) * time.Second
+		if p.arg(1) != "" {
+			expireSeconds, err := strconv.Atoi(p.arg(1))
+			if err != nil || expireSeconds <= 0 {
+				usageError(p.spec, fmt.Errorf("无效的过期时间: %s", p.arg(1)))
 			}
+			expireTime = time.Duration(expireSeconds) * time.Second
 		}
 		url, err := client.GetSignedURL(ossPath, expireTime)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "获取URL失败: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
 		fmt.Println("临时访问URL:")
 		fmt.Println(url)
 
-	default:
-		fmt.Printf("未知命令: %s\n", command)
-		printUsage()
-		os.Exit(1)
+	case "watch":
+		localPath := p.arg(0)
+		ossPath := p.arg(1)
+
+		watchOptions := &WatchOptions{
+			UploadOptions: UploadOptions{
+				WorkerCount: p.int("workers", 10),
+				Incremental: p.has("incremental"),
+				Symlinks:    p.str("symlinks", ""),
+				Retries:     flagRetries(p),
+			},
+			Delete:         p.has("delete"),
+			Debounce:       p.duration("debounce", 2*time.Second),
+			RescanInterval: p.duration("rescan", 10*time.Minute),
+		}
+		if p.has("exclude") {
+			watchOptions.ExcludePatterns = splitPatterns(p.str("exclude", ""))
+		}
+		if watchOptions.Debounce <= 0 {
+			usageError(p.spec, fmt.Errorf("--debounce 必须大于0"))
+		}
+
+		// 收到中断信号后处理完剩余的变化再退出
+		stop := make(chan struct{})
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-sigChan
+			close(stop)
+		}()
+
+		if err := client.Watch(localPath, ossPath, watchOptions, stop); err != nil {
+			fmt.Fprintf(os.Stderr, "监听失败: %v\n", err)
+			exit(1)
+		}
+
+	case "diff", "verify":
+		localPath := p.arg(0)
+		ossPath := p.arg(1)
+
+		compareOptions := &CompareOptions{
+			UploadOptions: UploadOptions{
+				WorkerCount: p.int("workers", 10),
+				Symlinks:    p.str("symlinks", ""),
+			},
+			Strict:   command == "verify" || p.has("strict"),
+			Download: p.has("download"),
+		}
+		if p.has("exclude") {
+			compareOptions.ExcludePatterns = splitPatterns(p.str("exclude", ""))
+		}
+
+		result, err := client.Compare(localPath, ossPath, compareOptions)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "比较失败: %v\n", err)
+			exit(2)
+		}
+		printCompareResult(result)
+
+		// diff: 0表示相同，1表示有差异，2表示出错；verify: 有任何问题都返回1
+		if command == "verify" {
+			if result.HasDifference() || len(result.Failed) > 0 {
+				fmt.Fprintln(os.Stderr, "校验失败!")
+				exit(1)
+			}
+			fmt.Println("校验通过!")
+		} else if len(result.Failed) > 0 {
+			exit(2)
+		} else if result.HasDifference() {
+			exit(1)
+		}
+
+	case "deploy":
+		if err := deployCommand(ctx, client, p); err != nil {
+			fmt.Fprintf(os.Stderr, "部署失败: %v\n", err)
+			exit(1)
+		}
+
+	case "verify-manifest":
+		verifyOptions := &VerifyManifestOptions{
+			Prefix:      p.str("prefix", ""),
+			Download:    p.has("download"),
+			WorkerCount: p.int("workers", 10),
+		}
+		result, err := client.VerifyManifest(ctx, p.arg(0), verifyOptions)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "校验清单失败: %v\n", err)
+			exit(2)
+		}
+		printManifestResult(result)
+		if result.HasProblem() {
+			fmt.Fprintln(os.Stderr, "校验失败!")
+			exit(1)
+		}
+		fmt.Println("校验通过!")
+
+	case "replicate":
+		if err := replicateCommand(ctx, client, p); err != nil {
+			fmt.Fprintf(os.Stderr, "复制失败: %v\n", err)
+			exit(1)
+		}
+
+	case "history":
+		if err := historyCommand(client, p); err != nil {
+			fmt.Fprintf(os.Stderr, "查询操作记录失败: %v\n", err)
+			exit(1)
+		}
+
+	case "trash":
+		if err := trashCommand(client, p); err != nil {
+			fmt.Fprintf(os.Stderr, "回收站操作失败: %v\n", err)
+			exit(1)
+		}
+
+	case "prune":
+		if err := pruneCommand(client, p); err != nil {
+			fmt.Fprintf(os.Stderr, "清理失败: %v\n", err)
+			exit(1)
+		}
+
+	case "tag":
+		if err := tagCommand(client, p); err != nil {
+			fmt.Fprintf(os.Stderr, "标签操作失败: %v\n", err)
+			exit(1)
+		}
+
+	case "shell":
+		if err := client.Shell(); err != nil {
+			fmt.Fprintf(os.Stderr, "交互模式失败: %v\n", err)
+			exit(1)
+		}
+
+	case "serve":
+		serveOptions := &ServeOptions{
+			Listen:    p.str("listen", "127.0.0.1:8080"),
+			Prefix:    p.str("prefix", ""),
+			Username:  p.str("user", ""),
+			Password:  p.str("password", os.Getenv("ALIOSS_PASSWORD")),
+			ReadOnly:  p.has("read-only"),
+			URLExpire: time.Duration(p.int("expire", 3600)) * time.Second,
+		}
+
+		stop := make(chan struct{})
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-sigChan
+			close(stop)
+		}()
+
+		if err := client.Serve(serveOptions, stop); err != nil {
+			fmt.Fprintf(os.Stderr, "Web服务失败: %v\n", err)
+			exit(1)
+		}
+
+	case "bucket":
+		if err := bucketCommand(client, p); err != nil {
+			fmt.Fprintf(os.Stderr, "Bucket操作失败: %v\n", err)
+			exit(1)
+		}
 	}
 }
@@ -0,0 +1,351 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// metaSHA256 生成清单时保存文件SHA256的对象元数据名称，校验清单时不需要下载文件
+const metaSHA256 = "Sha256"
+
+// uploadManifest 上传过程中收集的OSS路径和SHA256
+type uploadManifest struct {
+	mu      sync.Mutex
+	entries map[string]string
+}
+
+// newUploadManifest 创建空的上传清单
+func newUploadManifest() *uploadManifest {
+	return &uploadManifest{entries: make(map[string]string)}
+}
+
+// add 记录一个上传成功的文件
+func (m *uploadManifest) add(key, sum string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = sum
+}
+
+// addLocal 记录一个内容与OSS相同而跳过上传的文件
+func (m *uploadManifest) addLocal(file *localFile, key string) error {
+	if m == nil {
+		return nil
+	}
+	sum, err := localContentSHA256(file)
+	if err != nil {
+		return fmt.Errorf("计算SHA256失败: %v", err)
+	}
+	m.add(key, sum)
+	return nil
+}
+
+// write 按OSS路径排序，以SHA256SUMS格式写入清单文件，返回写入的文件数
+func (m *uploadManifest) write(manifestPath string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]string, 0, len(m.entries))
+	for key := range m.entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		fmt.Fprintf(&b, "%s  %s\n", m.entries[key], key)
+	}
+	if err := os.WriteFile(manifestPath, []byte(b.String()), 0644); err != nil {
+		return 0, fmt.Errorf("写入清单失败: %v", err)
+	}
+	return len(keys), nil
+}
+
+// manifestUploadOptions 生成上传时保存SHA256元数据的选项
+func manifestUploadOptions(file *localFile, options *UploadOptions) (string, []oss.Option, error) {
+	if options == nil || options.Manifest == nil {
+		return "", nil, nil
+	}
+	sum, err := localContentSHA256(file)
+	if err != nil {
+		return "", nil, fmt.Errorf("计算SHA256失败: %v", err)
+	}
+	return sum, []oss.Option{oss.Meta(metaSHA256, sum)}, nil
+}
+
+// localContentSHA256 计算本地文件或链接对象内容的SHA256
+func localContentSHA256(file *localFile) (string, error) {
+	if file.linkTarget != "" {
+		sum := sha256.Sum256([]byte(file.linkTarget))
+		return hex.EncodeToString(sum[:]), nil
+	}
+
+	f, err := os.Open(file.path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// uploadManifestFile 把清单文件上传到OSS，ossPath以斜杠结尾时使用清单的文件名
+func (c *OSSClient) uploadManifestFile(ctx context.Context, manifestPath, ossPath string) (string, error) {
+	ossPath = strings.TrimPrefix(ossPath, "/")
+	if ossPath == "" || strings.HasSuffix(ossPath, "/") {
+		ossPath += filepath.Base(manifestPath)
+	}
+
+	info, err := os.Stat(manifestPath)
+	if err != nil {
+		return "", fmt.Errorf("读取清单失败: %v", err)
+	}
+	file := &localFile{path: manifestPath, info: info}
+	if err := c.putLocalFile(ctx, file, ossPath, nil); err != nil {
+		return "", fmt.Errorf("上传清单失败: %v", err)
+	}
+	return ossPath, nil
+}
+
+// manifestEntry 清单中的一行
+type manifestEntry struct {
+	Sum string // SHA256
+	Key string // OSS路径
+}
+
+// readManifest 读取SHA256SUMS格式的清单，忽略空行和#开头的注释
+// 兼容sha256sum的二进制模式标记（文件名前的*）
+func readManifest(manifestPath string) ([]manifestEntry, error) {
+	f, err := os.Open(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取清单失败: %v", err)
+	}
+	defer f.Close()
+
+	var entries []manifestEntry
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		sum, key, ok := strings.Cut(line, " ")
+		key = strings.TrimPrefix(key, " ")
+		key = strings.TrimPrefix(key, "*")
+		if !ok || key == "" || len(sum) != sha256.Size*2 {
+			return nil, fmt.Errorf("清单第 %d 行格式错误: %s", lineNo, line)
+		}
+		if _, err := hex.DecodeString(sum); err != nil {
+			return nil, fmt.Errorf("清单第 %d 行的SHA256无效: %s", lineNo, sum)
+		}
+		entries = append(entries, manifestEntry{Sum: strings.ToLower(sum), Key: key})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取清单失败: %v", err)
+	}
+	return entries, nil
+}
+
+// VerifyManifestOptions 校验清单的选项
+type VerifyManifestOptions struct {
+	Prefix      string // 加在清单路径前的OSS前缀，用于清单中是相对路径的情况
+	Download    bool   // 没有SHA256元数据的文件下载内容计算SHA256
+	WorkerCount int    // 并发校验的协程数
+}
+
+// ManifestResult 清单校验结果
+type ManifestResult struct {
+	Missing    []string // OSS上不存在的文件
+	Mismatch   []string // SHA256不一致的文件
+	Unverified []string // 没有SHA256元数据，无法校验的文件
+	Failed     []string // 校验时出错的文件
+	OKCount    int      // 校验通过的文件数
+}
+
+// HasProblem 是否有文件未通过校验
+func (r *ManifestResult) HasProblem() bool {
+	return len(r.Missing) > 0 || len(r.Mismatch) > 0 || len(r.Unverified) > 0 || len(r.Failed) > 0
+}
+
+// 单个文件的校验结果
+const (
+	manifestOK = iota
+	manifestMissing
+	manifestMismatch
+	manifestUnverified
+	manifestFailed
+)
+
+// manifestTask 表示一个文件的校验任务
+type manifestTask struct {
+	key    string
+	sum    string
+	status int
+}
+
+// VerifyManifest 校验清单中的每个文件
+// 默认只读取上传时保存的SHA256元数据，不下载文件内容
+func (c *OSSClient) VerifyManifest(ctx context.Context, manifestPath string, options *VerifyManifestOptions) (*ManifestResult, error) {
+	if options == nil {
+		options = &VerifyManifestOptions{}
+	}
+
+	entries, err := readManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("正在校验 %d 个文件...\n", len(entries))
+
+	// 校验结果记录在任务中，调度器只负责并发、重试和中断，出错的文件由事件输出
+	scheduler := newTransferScheduler(ctx, "校验", options.WorkerCount, 0, func(event TransferEvent) {
+		switch event.Status {
+		case TransferFailed, TransferRetry:
+			printTransferEvent("校验", event)
+		case TransferProgress:
+			r := event.Result
+			fmt.Printf("校验中: 已完成 %d/%d 个文件\n", r.Done+r.Failed, r.Total)
+		}
+	})
+
+	prefix := strings.TrimPrefix(options.Prefix, "/")
+	tasks := make([]*manifestTask, len(entries))
+	for i, entry := range entries {
+		task := &manifestTask{key: path.Join(prefix, strings.TrimPrefix(entry.Key, "/")), sum: entry.Sum, status: manifestFailed}
+		tasks[i] = task
+		scheduler.submit(&transferTask{
+			name: task.key,
+			run: func(ctx context.Context) (string, error) {
+				status, err := c.verifyManifestEntry(ctx, task.key, task.sum, options.Download)
+				task.status = status
+				if err != nil {
+					return "", err
+				}
+				return task.key, nil
+			},
+		})
+	}
+	if transferResult := scheduler.wait(); transferResult.Canceled > 0 {
+		return nil, transferResult.err("校验")
+	}
+
+	result := &ManifestResult{}
+	for _, task := range tasks {
+		switch task.status {
+		case manifestOK:
+			result.OKCount++
+		case manifestMissing:
+			result.Missing = append(result.Missing, task.key)
+		case manifestMismatch:
+			result.Mismatch = append(result.Mismatch, task.key)
+		case manifestUnverified:
+			result.Unverified = append(result.Unverified, task.key)
+		default:
+			result.Failed = append(result.Failed, task.key)
+		}
+	}
+	return result, nil
+}
+
+// verifyManifestEntry 校验单个文件，优先使用SHA256元数据，download为true时没有元数据的文件下载后计算
+func (c *OSSClient) verifyManifestEntry(ctx context.Context, key, sum string, download bool) (int, error) {
+	meta, err := c.bucket.GetObjectDetailedMeta(key, oss.WithContext(ctx))
+	if err != nil {
+		if isNotFound(err) {
+			return manifestMissing, nil
+		}
+		return manifestFailed, fmt.Errorf("获取远程文件元信息失败: %v", err)
+	}
+
+	remote := strings.ToLower(meta.Get(oss.HTTPHeaderOssMetaPrefix + metaSHA256))
+	if remote == "" {
+		if !download {
+			return manifestUnverified, nil
+		}
+		if remote, err = c.remoteSHA256(ctx, key); err != nil {
+			return manifestFailed, err
+		}
+	}
+
+	if remote != sum {
+		return manifestMismatch, nil
+	}
+	return manifestOK, nil
+}
+
+// remoteSHA256 下载文件内容计算SHA256，不写入本地磁盘
+func (c *OSSClient) remoteSHA256(ctx context.Context, key string) (string, error) {
+	body, err := c.bucket.GetObject(key, oss.WithContext(ctx))
+	if err != nil {
+		return "", fmt.Errorf("下载文件失败: %v", err)
+	}
+	defer body.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, body); err != nil {
+		return "", fmt.Errorf("下载文件失败: %v", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// printManifestResult 输出清单校验结果
+func printManifestResult(result *ManifestResult) {
+	for _, key := range result.Missing {
+		fmt.Printf("- 不存在: %s\n", key)
+	}
+	for _, key := range result.Mismatch {
+		fmt.Printf("! SHA256不一致: %s\n", key)
+	}
+	for _, key := range result.Unverified {
+		fmt.Printf("? 没有SHA256元数据: %s\n", key)
+	}
+
+	fmt.Printf("通过: %d, 不存在: %d, 不一致: %d, 无法校验: %d",
+		result.OKCount, len(result.Missing), len(result.Mismatch), len(result.Unverified))
+	if len(result.Failed) > 0 {
+		fmt.Printf(", 出错: %d", len(result.Failed))
+	}
+	fmt.Println()
+	if len(result.Unverified) > 0 {
+		fmt.Println("提示: 不是使用 --manifest 上传的文件没有SHA256元数据，可以使用 --download 下载内容校验")
+	}
+}
+
+// finishUploadManifest 上传结束后写入清单，ossPath不为空时把清单也上传到OSS
+// 上传出错时仍写入已上传成功的文件，但不上传清单，避免发布不完整的清单
+func (c *OSSClient) finishUploadManifest(ctx context.Context, manifest *uploadManifest, manifestPath, ossPath string, uploadErr error, out io.Writer) error {
+	count, err := manifest.write(manifestPath)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "已写入清单: %s（%d 个文件）\n", manifestPath, count)
+
+	if ossPath == "" || uploadErr != nil {
+		return nil
+	}
+	key, err := c.uploadManifestFile(ctx, manifestPath, ossPath)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "已上传清单: %s\n", key)
+	return nil
+}
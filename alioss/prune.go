@@ -0,0 +1,322 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"gopkg.in/yaml.v3"
+)
+
+// deleteBatchSize OSS批量删除每次最多1000个文件
+const deleteBatchSize = 1000
+
+// PruneRule 一条保留规则，可以从YAML文件加载
+type PruneRule struct {
+	Prefix    string   `yaml:"prefix"`    // 规则作用的前缀
+	OlderThan string   `yaml:"olderThan"` // 删除早于该时间的文件，如 30d、12h、2w
+	KeepLast  int      `yaml:"keepLast"`  // 每组保留最新的N个文件
+	GroupBy   string   `yaml:"groupBy"`   // 分组正则，有捕获组时按第一个捕获组分组，否则按整个匹配分组
+	Include   []string `yaml:"include"`   // 只处理匹配这些模式的文件
+	Exclude   []string `yaml:"exclude"`   // 不处理匹配这些模式的文件
+}
+
+// PruneConfig 保留规则文件
+type PruneConfig struct {
+	Rules []PruneRule `yaml:"rules"`
+}
+
+// PrunePlan 一条规则的清理计划
+type PrunePlan struct {
+	Rule       PruneRule
+	Delete     []oss.ObjectProperties // 将被删除的文件
+	KeepCount  int                    // 保留的文件数
+	DeleteSize int64                  // 将被删除的总大小
+}
+
+// loadPruneConfig 从YAML文件加载保留规则
+func loadPruneConfig(configPath string) (*PruneConfig, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取规则文件失败: %v", err)
+	}
+
+	var config PruneConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("解析规则文件失败: %v", err)
+	}
+	if len(config.Rules) == 0 {
+		return nil, fmt.Errorf("规则文件中没有规则: %s", configPath)
+	}
+
+	return &config, nil
+}
+
+// parseAge 解析时间长度，在time.ParseDuration的基础上支持 d（天）和 w（周）
+func parseAge(value string) (time.Duration, error) {
+	unit := time.Duration(0)
+	switch {
+	case strings.HasSuffix(value, "d"):
+		unit = 24 * time.Hour
+	case strings.HasSuffix(value, "w"):
+		unit = 7 * 24 * time.Hour
+	}
+
+	if unit > 0 {
+		n, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSuffix(value, "d"), "w"), 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("无效的时间长度: %s", value)
+		}
+		return time.Duration(n * float64(unit)), nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("无效的时间长度: %s，示例: 30d、2w、12h", value)
+	}
+	return d, nil
+}
+
+// matchKeyPattern 判断文件是否匹配模式，模式可以匹配相对路径或文件名
+func matchKeyPattern(relPath string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, relPath); matched {
+			return true
+		}
+		if matched, _ := path.Match(pattern, path.Base(relPath)); matched {
+			return true
+		}
+		// 以斜杠结尾的模式匹配目录下的所有文件
+		if strings.HasSuffix(pattern, "/") && strings.HasPrefix(relPath, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// PlanPrune 根据规则计算需要删除的文件，不做任何修改
+func (c *OSSClient) PlanPrune(rule PruneRule, now time.Time) (*PrunePlan, error) {
+	if strings.Trim(rule.Prefix, "/") == "" {
+		return nil, fmt.Errorf("规则必须指定前缀，不允许清理整个Bucket")
+	}
+	if rule.OlderThan == "" && rule.KeepLast <= 0 {
+		return nil, fmt.Errorf("规则 %s 至少需要指定 olderThan 或 keepLast", rule.Prefix)
+	}
+
+	// 标准化前缀，去除前导斜杠并补全结尾的斜杠，避免logs匹配到logs-archive
+	rule.Prefix = strings.TrimPrefix(rule.Prefix, "/")
+	if !strings.HasSuffix(rule.Prefix, "/") {
+		rule.Prefix += "/"
+	}
+
+	var cutoff time.Time
+	if rule.OlderThan != "" {
+		age, err := parseAge(rule.OlderThan)
+		if err != nil {
+			return nil, err
+		}
+		cutoff = now.Add(-age)
+	}
+
+	var groupBy *regexp.Regexp
+	if rule.GroupBy != "" {
+		var err error
+		if groupBy, err = regexp.Compile(rule.GroupBy); err != nil {
+			return nil, fmt.Errorf("无效的分组正则: %v", err)
+		}
+	}
+
+	objects, err := c.ListObjects(rule.Prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &PrunePlan{Rule: rule}
+
+	// 按分组收集候选文件
+	groups := make(map[string][]oss.ObjectProperties)
+	for _, object := range objects {
+		relPath := strings.TrimPrefix(object.Key, rule.Prefix)
+		if relPath == "" || strings.HasSuffix(relPath, "/") {
+			continue
+		}
+		if len(rule.Include) > 0 && !matchKeyPattern(relPath, rule.Include) {
+			plan.KeepCount++
+			continue
+		}
+		if matchKeyPattern(relPath, rule.Exclude) {
+			plan.KeepCount++
+			continue
+		}
+
+		group := ""
+		if groupBy != nil {
+			match := groupBy.FindStringSubmatch(relPath)
+			if match == nil {
+				// 不匹配分组正则的文件不受规则影响
+				plan.KeepCount++
+				continue
+			}
+			group = match[0]
+			if len(match) > 1 {
+				group = match[1]
+			}
+		}
+		groups[group] = append(groups[group], object)
+	}
+
+	for _, group := range groups {
+		// 按修改时间从新到旧排序
+		sort.Slice(group, func(i, j int) bool {
+			return group[i].LastModified.After(group[j].LastModified)
+		})
+
+		for i, object := range group {
+			expired := true
+			if rule.KeepLast > 0 && i < rule.KeepLast {
+				expired = false
+			}
+			if rule.OlderThan != "" && !object.LastModified.Before(cutoff) {
+				expired = false
+			}
+
+			if expired {
+				plan.Delete = append(plan.Delete, object)
+				plan.DeleteSize += object.Size
+			} else {
+				plan.KeepCount++
+			}
+		}
+	}
+
+	sort.Slice(plan.Delete, func(i, j int) bool { return plan.Delete[i].Key < plan.Delete[j].Key })
+	return plan, nil
+}
+
+// printPrunePlan 输出清理计划
+func printPrunePlan(plan *PrunePlan) {
+	fmt.Printf("规则: %s", plan.Rule.Prefix)
+	if plan.Rule.OlderThan != "" {
+		fmt.Printf(" 早于%s", plan.Rule.OlderThan)
+	}
+	if plan.Rule.KeepLast > 0 {
+		fmt.Printf(" 保留最新%d个", plan.Rule.KeepLast)
+	}
+	if plan.Rule.GroupBy != "" {
+		fmt.Printf(" 按 %s 分组", plan.Rule.GroupBy)
+	}
+	fmt.Println()
+
+	for _, object := range plan.Delete {
+		fmt.Printf("  - %s (%s, %s)\n", object.Key, formatSize(object.Size), object.LastModified.Local().Format("2006-01-02 15:04:05"))
+	}
+	fmt.Printf("  将删除 %d 个文件 (%s)，保留 %d 个文件\n", len(plan.Delete), formatSize(plan.DeleteSize), plan.KeepCount)
+}
+
+// DeleteObjectsBatch 批量删除文件，每批最多1000个
+func (c *OSSClient) DeleteObjectsBatch(keys []string) error {
+	deleted := 0
+	for start := 0; start < len(keys); start += deleteBatchSize {
+		end := start + deleteBatchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		_, err := c.bucket.DeleteObjects(keys[start:end], oss.DeleteObjectsQuiet(true))
+		c.audit(AuditEntry{Op: AuditDelete, Keys: keys[start:end]}, err)
+		if err != nil {
+			return fmt.Errorf("批量删除失败（已删除 %d 个文件）: %v", deleted, err)
+		}
+
+		deleted += end - start
+		fmt.Printf("已删除 %d/%d 个文件\n", deleted, len(keys))
+	}
+	return nil
+}
+
+// Prune 按规则计算清理计划并输出，apply为true时执行删除
+func (c *OSSClient) Prune(rules []PruneRule, apply bool) error {
+	now := time.Now()
+
+	var plans []*PrunePlan
+	total := 0
+	for _, rule := range rules {
+		plan, err := c.PlanPrune(rule, now)
+		if err != nil {
+			return err
+		}
+		printPrunePlan(plan)
+		plans = append(plans, plan)
+		total += len(plan.Delete)
+	}
+
+	if !apply {
+		fmt.Printf("\n预览模式: 共 %d 个文件将被删除，使用 --apply 执行删除\n", total)
+		return nil
+	}
+	if total == 0 {
+		fmt.Println("\n没有需要删除的文件")
+		return nil
+	}
+
+	// 多条规则可能选中同一个文件，删除前去重
+	seen := make(map[string]bool)
+	var keys []string
+	for _, plan := range plans {
+		for _, object := range plan.Delete {
+			if !seen[object.Key] {
+				seen[object.Key] = true
+				keys = append(keys, object.Key)
+			}
+		}
+	}
+
+	fmt.Printf("\n开始删除 %d 个文件...\n", len(keys))
+	return c.DeleteObjectsBatch(keys)
+}
+
+// pruneCommand 处理 prune 命令
+func pruneCommand(client *OSSClient, p *parsedArgs) error {
+	rule := PruneRule{
+		Prefix:    p.arg(0),
+		OlderThan: p.str("older-than", ""),
+		KeepLast:  p.int("keep-last", 0),
+		GroupBy:   p.str("group-by", ""),
+	}
+	if p.has("include") {
+		rule.Include = splitPatterns(p.str("include", ""))
+	}
+	if p.has("exclude") {
+		rule.Exclude = splitPatterns(p.str("exclude", ""))
+	}
+	rulesFile := p.str("rules", "")
+	apply := p.has("apply")
+
+	if rulesFile != "" {
+		config, err := loadPruneConfig(rulesFile)
+		if err != nil {
+			return err
+		}
+		return client.Prune(config.Rules, apply)
+	}
+
+	if rule.Prefix == "" {
+		return fmt.Errorf("用法: alioss prune <前缀> [--older-than 30d] [--keep-last N] [--group-by 正则] [--include 模式,...] [--exclude 模式,...] [--apply] 或 alioss prune --rules 规则文件.yaml [--apply]")
+	}
+	return client.Prune([]PruneRule{rule}, apply)
+}
+
+// splitPatterns 拆分逗号分隔的模式列表
+func splitPatterns(value string) []string {
+	patterns := strings.Split(value, ",")
+	for i, pattern := range patterns {
+		patterns[i] = strings.TrimSpace(pattern)
+	}
+	return patterns
+}
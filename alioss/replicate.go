@@ -0,0 +1,551 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// ReplicaEndpoint 复制的源或目标，格式为 [配置名:]oss://bucket/前缀
+type ReplicaEndpoint struct {
+	Profile string // 命名配置，为空时使用当前配置
+	Bucket  string
+	Prefix  string
+}
+
+// ReplicateOptions 复制选项
+type ReplicateOptions struct {
+	WorkerCount int    // 并发复制的工作协程数
+	Stream      bool   // 强制通过本机中转（下载后上传），不使用服务端拷贝
+	DryRun      bool   // 只显示需要复制的文件
+	Verify      bool   // 复制后校验目标文件
+	JournalPath string // 进度记录文件，为空时使用默认路径
+	Retries     int    // 失败重试次数，0使用默认值，负数表示不重试
+}
+
+// parseReplicaEndpoint 解析 [配置名:]oss://bucket/前缀
+func parseReplicaEndpoint(spec string) (ReplicaEndpoint, error) {
+	index := strings.Index(spec, "oss://")
+	if index < 0 {
+		return ReplicaEndpoint{}, fmt.Errorf("无效的位置: %s，格式应为 [配置名:]oss://bucket/前缀", spec)
+	}
+
+	endpoint := ReplicaEndpoint{}
+	if index > 0 {
+		if spec[index-1] != ':' || index == 1 {
+			return ReplicaEndpoint{}, fmt.Errorf("无效的位置: %s，格式应为 [配置名:]oss://bucket/前缀", spec)
+		}
+		endpoint.Profile = spec[:index-1]
+	}
+
+	endpoint.Bucket, endpoint.Prefix, _ = strings.Cut(spec[index+len("oss://"):], "/")
+	if endpoint.Bucket == "" {
+		return ReplicaEndpoint{}, fmt.Errorf("无效的位置: %s，缺少Bucket名称", spec)
+	}
+	return endpoint, nil
+}
+
+// String 返回 [配置名:]oss://bucket/前缀 形式的位置
+func (e ReplicaEndpoint) String() string {
+	s := "oss://" + e.Bucket + "/" + e.Prefix
+	if e.Profile != "" {
+		s = e.Profile + ":" + s
+	}
+	return s
+}
+
+// replicaJournal 记录已完成复制的文件，中断后重新运行时跳过这些文件
+// 每行格式为 源文件路径<Tab>源文件ETag，源文件变化后会重新复制
+type replicaJournal struct {
+	mu   sync.Mutex
+	path string
+	done map[string]string
+	file *os.File
+}
+
+// defaultReplicaJournalPath 根据源和目标生成默认的进度记录文件路径
+func defaultReplicaJournalPath(from, to ReplicaEndpoint) (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("获取用户目录失败: %v", err)
+	}
+	sum := sha1.Sum([]byte(from.String() + "\n" + to.String()))
+	return filepath.Join(homeDir, ".alioss_replicate_"+hex.EncodeToString(sum[:6])+".journal"), nil
+}
+
+// openReplicaJournal 读取已有的进度记录，记录文件在第一次写入时创建
+func openReplicaJournal(path string) (*replicaJournal, error) {
+	journal := &replicaJournal{path: path, done: make(map[string]string)}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return journal, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取进度记录失败: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, etag, ok := strings.Cut(scanner.Text(), "\t")
+		if ok {
+			journal.done[key] = etag
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取进度记录失败: %v", err)
+	}
+	if len(journal.done) > 0 {
+		fmt.Printf("从进度记录继续: %s (%d 个文件已完成)\n", path, len(journal.done))
+	}
+	return journal, nil
+}
+
+// isDone 判断文件是否已经复制过且源文件没有变化
+func (j *replicaJournal) isDone(key, etag string) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	done, ok := j.done[key]
+	return ok && done == etag
+}
+
+// record 记录文件已完成复制
+func (j *replicaJournal) record(key, etag string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.done[key] = etag
+	if j.file == nil {
+		file, err := os.OpenFile(j.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		j.file = file
+	}
+	_, err := fmt.Fprintf(j.file, "%s\t%s\n", key, etag)
+	return err
+}
+
+// forget 从进度记录中删除文件，下次运行时重新复制
+// 记录文件只追加，删除时写入临时文件后替换原文件
+func (j *replicaJournal) forget(keys []string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, key := range keys {
+		delete(j.done, key)
+	}
+	if j.file != nil {
+		j.file.Close()
+		j.file = nil
+	}
+
+	var b strings.Builder
+	for key, etag := range j.done {
+		fmt.Fprintf(&b, "%s\t%s\n", key, etag)
+	}
+	tmpPath := j.path + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(b.String()), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, j.path)
+}
+
+// close 关闭进度记录文件，remove为true时删除（全部完成后不再需要）
+func (j *replicaJournal) close(remove bool) {
+	if j.file != nil {
+		j.file.Close()
+	}
+	if remove {
+		os.Remove(j.path)
+	}
+}
+
+// replicator 执行一次源到目标的复制
+type replicator struct {
+	src, dest  *OSSClient
+	from, to   ReplicaEndpoint
+	serverSide bool // 源和目标在同一地域和账号下，可以使用服务端拷贝
+}
+
+// sameService 判断两个客户端是否使用相同的Endpoint和AccessKey
+func sameService(a, b *OSSClient) bool {
+	normalize := func(endpoint string) string {
+		endpoint = strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(endpoint), "https://"), "http://")
+		return strings.TrimSuffix(endpoint, "/")
+	}
+	return normalize(a.config.EndPoint) == normalize(b.config.EndPoint) && a.config.ID == b.config.ID
+}
+
+// destKey 源文件在目标中的路径
+func (r *replicator) destKey(srcKey string) string {
+	return r.to.Prefix + strings.TrimPrefix(srcKey, r.from.Prefix)
+}
+
+// objectCRC64 获取文件的CRC64校验值
+func objectCRC64(client *OSSClient, key string) (string, error) {
+	meta, err := client.bucket.GetObjectDetailedMeta(key)
+	if err != nil {
+		return "", err
+	}
+	return meta.Get(oss.HTTPHeaderOssCRC64), nil
+}
+
+// sameObject 判断源文件和目标文件内容是否相同
+// ETag相同即认为相同；分片上传的文件ETag与分片大小有关，此时比较CRC64
+func (r *replicator) sameObject(src, dest oss.ObjectProperties) (bool, error) {
+	if src.Size != dest.Size {
+		return false, nil
+	}
+	if src.ETag == dest.ETag {
+		return true, nil
+	}
+	if !strings.Contains(src.ETag, "-") && !strings.Contains(dest.ETag, "-") {
+		// 都是普通上传时ETag就是MD5，不同即内容不同
+		return false, nil
+	}
+
+	srcCRC, err := objectCRC64(r.src, src.Key)
+	if err != nil {
+		return false, fmt.Errorf("获取源文件信息失败: %v", err)
+	}
+	destCRC, err := objectCRC64(r.dest, dest.Key)
+	if err != nil {
+		return false, fmt.Errorf("获取目标文件信息失败: %v", err)
+	}
+	return srcCRC != "" && srcCRC == destCRC, nil
+}
+
+// objectMetaOptions 从源文件的响应头生成上传选项，保留内容类型和自定义元数据
+func objectMetaOptions(header http.Header) []oss.Option {
+	var options []oss.Option
+	if value := header.Get(oss.HTTPHeaderContentType); value != "" {
+		options = append(options, oss.ContentType(value))
+	}
+	if value := header.Get(oss.HTTPHeaderContentDisposition); value != "" {
+		options = append(options, oss.ContentDisposition(value))
+	}
+	if value := header.Get(oss.HTTPHeaderCacheControl); value != "" {
+		options = append(options, oss.CacheControl(value))
+	}
+	if value := header.Get(oss.HTTPHeaderContentEncoding); value != "" {
+		options = append(options, oss.ContentEncoding(value))
+	}
+	for name, values := range header {
+		if len(values) > 0 && strings.HasPrefix(name, oss.HTTPHeaderOssMetaPrefix) {
+			options = append(options, oss.Meta(strings.TrimPrefix(name, oss.HTTPHeaderOssMetaPrefix), values[0]))
+		}
+	}
+	return options
+}
+
+// copyServerSide 使用服务端拷贝复制文件，大文件使用分片拷贝
+func (r *replicator) copyServerSide(ctx context.Context, object oss.ObjectProperties, destKey string) error {
+	if object.Size <= maxCopyObjectSize {
+		_, err := r.dest.bucket.CopyObjectFrom(r.from.Bucket, object.Key, destKey, oss.WithContext(ctx))
+		return err
+	}
+
+	// 分片拷贝不会自动复制元数据
+	meta, err := r.src.bucket.GetObjectDetailedMeta(object.Key)
+	if err != nil {
+		return fmt.Errorf("获取源文件信息失败: %w", err)
+	}
+	return r.dest.bucket.CopyFile(r.from.Bucket, object.Key, destKey, copyPartSize, append(objectMetaOptions(meta), oss.WithContext(ctx))...)
+}
+
+// copyStream 通过本机中转复制文件，大文件按范围读取后分片上传
+// 读取时要求ETag不变，避免复制过程中源文件被修改导致内容混杂
+func (r *replicator) copyStream(ctx context.Context, object oss.ObjectProperties, destKey string) error {
+	ifMatch := oss.IfMatch(object.ETag)
+	withCtx := oss.WithContext(ctx)
+
+	if object.Size <= copyPartSize {
+		result, err := r.src.bucket.DoGetObject(&oss.GetObjectRequest{ObjectKey: object.Key}, []oss.Option{ifMatch, withCtx})
+		if err != nil {
+			return fmt.Errorf("读取源文件失败: %w", err)
+		}
+		defer result.Response.Close()
+
+		options := append(objectMetaOptions(result.Response.Headers), oss.ContentLength(object.Size), withCtx)
+		return r.dest.bucket.PutObject(destKey, result.Response.Body, options...)
+	}
+
+	meta, err := r.src.bucket.GetObjectDetailedMeta(object.Key, ifMatch)
+	if err != nil {
+		return fmt.Errorf("获取源文件信息失败: %w", err)
+	}
+	imur, err := r.dest.bucket.InitiateMultipartUpload(destKey, objectMetaOptions(meta)...)
+	if err != nil {
+		return fmt.Errorf("初始化分片上传失败: %w", err)
+	}
+
+	var parts []oss.UploadPart
+	for start, partNumber := int64(0), 1; start < object.Size; start, partNumber = start+copyPartSize, partNumber+1 {
+		end := start + copyPartSize
+		if end > object.Size {
+			end = object.Size
+		}
+
+		// 中断时取消分片上传，不留下未完成的分片
+		if err := ctx.Err(); err != nil {
+			r.dest.bucket.AbortMultipartUpload(imur)
+			return err
+		}
+		body, err := r.src.bucket.GetObject(object.Key, oss.Range(start, end-1), ifMatch, withCtx)
+		if err != nil {
+			r.dest.bucket.AbortMultipartUpload(imur)
+			return fmt.Errorf("读取源文件失败: %w", err)
+		}
+		part, err := r.dest.bucket.UploadPart(imur, body, end-start, partNumber, withCtx)
+		body.Close()
+		if err != nil {
+			r.dest.bucket.AbortMultipartUpload(imur)
+			return fmt.Errorf("上传分片 %d 失败: %w", partNumber, err)
+		}
+		parts = append(parts, part)
+	}
+
+	if _, err := r.dest.bucket.CompleteMultipartUpload(imur, parts); err != nil {
+		r.dest.bucket.AbortMultipartUpload(imur)
+		return fmt.Errorf("完成分片上传失败: %w", err)
+	}
+	return nil
+}
+
+// copy 复制一个文件并记录审计日志
+func (r *replicator) copy(ctx context.Context, object oss.ObjectProperties) error {
+	destKey := r.destKey(object.Key)
+
+	var err error
+	if r.serverSide {
+		err = r.copyServerSide(ctx, object, destKey)
+	} else {
+		err = r.copyStream(ctx, object, destKey)
+	}
+
+	source := "oss://" + r.from.Bucket
+	if r.from.Profile != "" {
+		source = r.from.Profile + ":" + source
+	}
+	r.dest.auditContext(ctx, AuditEntry{Op: AuditCopy, Keys: []string{object.Key}, Dest: destKey, Bytes: object.Size, Detail: "from " + source}, err)
+	return err
+}
+
+// verify 重新列出目标文件，检查每个源文件在目标中都存在且内容相同，返回不一致的文件
+func (r *replicator) verify(objects []oss.ObjectProperties) ([]string, error) {
+	destObjects, err := r.dest.ListObjects(r.to.Prefix)
+	if err != nil {
+		return nil, fmt.Errorf("获取目标文件列表失败: %v", err)
+	}
+	destMap := make(map[string]oss.ObjectProperties, len(destObjects))
+	for _, object := range destObjects {
+		destMap[object.Key] = object
+	}
+
+	srcMap := make(map[string]oss.ObjectProperties, len(objects))
+	keys := make([]string, len(objects))
+	for i, object := range objects {
+		srcMap[object.Key] = object
+		keys[i] = object.Key
+	}
+
+	var mu sync.Mutex
+	var mismatched []string
+	failCount := forEachKey("校验", keys, 0, func(key string) error {
+		dest, ok := destMap[r.destKey(key)]
+		same := false
+		if ok {
+			var err error
+			if same, err = r.sameObject(srcMap[key], dest); err != nil {
+				return err
+			}
+		}
+		if !same {
+			mu.Lock()
+			mismatched = append(mismatched, key)
+			mu.Unlock()
+		}
+		return nil
+	})
+	if failCount > 0 {
+		return mismatched, fmt.Errorf("%d 个文件校验时出错", failCount)
+	}
+	return mismatched, nil
+}
+
+// Replicate 将源前缀下的文件复制到目标前缀，只复制目标中不存在或内容不同的文件
+func (c *OSSClient) Replicate(ctx context.Context, from, to ReplicaEndpoint, options *ReplicateOptions) error {
+	src, err := c.openProfile(from.Profile, from.Bucket)
+	if err != nil {
+		return fmt.Errorf("连接源失败: %v", err)
+	}
+	dest, err := c.openProfile(to.Profile, to.Bucket)
+	if err != nil {
+		return fmt.Errorf("连接目标失败: %v", err)
+	}
+
+	r := &replicator{
+		src:        src,
+		dest:       dest,
+		from:       from,
+		to:         to,
+		serverSide: sameService(src, dest) && !options.Stream,
+	}
+	if sameService(src, dest) && from.Bucket == to.Bucket &&
+		(strings.HasPrefix(from.Prefix, to.Prefix) || strings.HasPrefix(to.Prefix, from.Prefix)) {
+		return fmt.Errorf("源和目标的前缀不能互相包含")
+	}
+
+	journalPath := options.JournalPath
+	if journalPath == "" {
+		if journalPath, err = defaultReplicaJournalPath(from, to); err != nil {
+			return err
+		}
+	}
+	journal, err := openReplicaJournal(journalPath)
+	if err != nil {
+		return err
+	}
+	completed := false
+	defer func() { journal.close(completed) }()
+
+	objects, err := src.ListObjects(from.Prefix)
+	if err != nil {
+		return fmt.Errorf("获取源文件列表失败: %v", err)
+	}
+	destObjects, err := dest.ListObjects(to.Prefix)
+	if err != nil {
+		return fmt.Errorf("获取目标文件列表失败: %v", err)
+	}
+	destMap := make(map[string]oss.ObjectProperties, len(destObjects))
+	for _, object := range destObjects {
+		destMap[object.Key] = object
+	}
+
+	mode := "服务端拷贝"
+	if !r.serverSide {
+		mode = "本机中转"
+	}
+	fmt.Printf("从 %s 复制到 %s（%s），源文件 %d 个\n", from, to, mode, len(objects))
+
+	// 找出需要复制的文件
+	srcMap := make(map[string]oss.ObjectProperties, len(objects))
+	var pending []string
+	var pendingSize int64
+	skipped := 0
+	for _, object := range objects {
+		srcMap[object.Key] = object
+		if journal.isDone(object.Key, object.ETag) {
+			skipped++
+			continue
+		}
+		if destObject, ok := destMap[r.destKey(object.Key)]; ok {
+			same, err := r.sameObject(object, destObject)
+			if err != nil {
+				return err
+			}
+			if same {
+				if !options.DryRun {
+					journal.record(object.Key, object.ETag)
+				}
+				skipped++
+				continue
+			}
+		}
+		pending = append(pending, object.Key)
+		pendingSize += object.Size
+	}
+
+	if options.DryRun {
+		for _, key := range pending {
+			fmt.Printf("将复制: %s -> %s (%s)\n", key, r.destKey(key), formatSize(srcMap[key].Size))
+		}
+		fmt.Printf("预览模式: 将复制 %d 个文件 (%s)，跳过 %d 个未变化的文件\n", len(pending), formatSize(pendingSize), skipped)
+		return nil
+	}
+
+	scheduler := newTransferScheduler(ctx, "复制", options.WorkerCount, options.Retries, nil)
+	for _, key := range pending {
+		object := srcMap[key]
+		scheduler.submit(&transferTask{
+			name: key,
+			size: object.Size,
+			run: func(ctx context.Context) (string, error) {
+				if err := r.copy(ctx, object); err != nil {
+					return "", err
+				}
+				if err := journal.record(key, object.ETag); err != nil {
+					fmt.Fprintf(os.Stderr, "警告: 写入进度记录失败: %v\n", err)
+				}
+				return r.destKey(key), nil
+			},
+		})
+	}
+	result := scheduler.wait()
+
+	printTransferResult("复制", result)
+	fmt.Printf("，跳过 %d 个未变化的文件\n", skipped)
+	if err := result.err("复制"); err != nil {
+		return fmt.Errorf("%v，重新运行相同命令将从中断处继续", err)
+	}
+
+	if options.Verify {
+		fmt.Println("校验目标文件...")
+		mismatched, err := r.verify(objects)
+		for _, key := range mismatched {
+			fmt.Fprintf(os.Stderr, "校验失败: %s\n", r.destKey(key))
+		}
+		// 不一致的文件下次需要重新复制，不能再按进度记录跳过
+		if len(mismatched) > 0 {
+			if err := journal.forget(mismatched); err != nil {
+				return fmt.Errorf("%d 个文件校验失败，更新进度记录失败: %v，请删除 %s 后重新运行复制", len(mismatched), err, journalPath)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if len(mismatched) > 0 {
+			return fmt.Errorf("%d 个文件校验失败，请重新运行复制", len(mismatched))
+		}
+		fmt.Printf("校验通过: %d 个文件\n", len(objects))
+	}
+
+	completed = true
+	return nil
+}
+
+// replicateCommand 处理 replicate 命令
+func replicateCommand(ctx context.Context, client *OSSClient, p *parsedArgs) error {
+	options := &ReplicateOptions{
+		WorkerCount: p.int("workers", 10),
+		JournalPath: p.str("journal", ""),
+		Stream:      p.has("stream"),
+		DryRun:      p.has("dry-run"),
+		Verify:      !p.has("no-verify"),
+		Retries:     flagRetries(p),
+	}
+	fromSpec, toSpec := p.str("from", ""), p.str("to", "")
+
+	if fromSpec == "" || toSpec == "" {
+		return fmt.Errorf("用法: alioss replicate --from [配置名:]oss://bucket/前缀 --to [配置名:]oss://bucket/前缀 [--workers N] [--stream] [--dry-run] [--no-verify] [--journal 文件] [--retries N]")
+	}
+	from, err := parseReplicaEndpoint(fromSpec)
+	if err != nil {
+		return err
+	}
+	to, err := parseReplicaEndpoint(toSpec)
+	if err != nil {
+		return err
+	}
+
+	return client.Replicate(ctx, from, to, options)
+}
@@ -0,0 +1,378 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// ServeOptions 本地Web界面选项
+type ServeOptions struct {
+	Listen    string        // 监听地址，如 :8080
+	Prefix    string        // 只允许访问该前缀下的文件
+	Username  string        // 基本认证用户名
+	Password  string        // 基本认证密码，为空时自动生成
+	ReadOnly  bool          // 只读模式，禁止上传
+	URLExpire time.Duration // 签名链接的有效期
+}
+
+// webServer 处理Web界面的请求
+type webServer struct {
+	client  *OSSClient
+	options *ServeOptions
+}
+
+// webEntry 页面上显示的一个目录或文件
+type webEntry struct {
+	Name     string
+	Path     string // 相对于服务前缀的路径
+	Size     string
+	Modified string
+}
+
+// webPage 目录页面数据
+type webPage struct {
+	Prefix   string
+	Path     string
+	Crumbs   []webEntry
+	Dirs     []webEntry
+	Files    []webEntry
+	ReadOnly bool
+}
+
+// Serve 启动本地Web界面，浏览、下载和上传前缀下的文件，收到stop信号后退出
+func (c *OSSClient) Serve(options *ServeOptions, stop <-chan struct{}) error {
+	// 标准化前缀，去除前导斜杠并确保以斜杠结尾
+	options.Prefix = strings.TrimPrefix(options.Prefix, "/")
+	if options.Prefix != "" && !strings.HasSuffix(options.Prefix, "/") {
+		options.Prefix += "/"
+	}
+	if options.Username == "" {
+		options.Username = "alioss"
+	}
+	if options.URLExpire <= 0 {
+		options.URLExpire = time.Hour
+	}
+
+	// 未指定密码时生成随机密码，避免界面在无认证的情况下暴露
+	if options.Password == "" {
+		buf := make([]byte, 8)
+		if _, err := rand.Read(buf); err != nil {
+			return fmt.Errorf("生成密码失败: %v", err)
+		}
+		options.Password = hex.EncodeToString(buf)
+		fmt.Printf("未指定密码，已生成随机密码: %s\n", options.Password)
+	}
+
+	s := &webServer{client: c, options: options}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.handleIndex)
+	mux.HandleFunc("GET /download", s.handleDownload)
+	mux.HandleFunc("GET /sign", s.handleSign)
+	mux.HandleFunc("PUT /upload", s.handleUpload)
+
+	server := &http.Server{
+		Addr:              options.Listen,
+		Handler:           s.basicAuth(mux),
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- server.ListenAndServe()
+	}()
+
+	mode := "读写"
+	if options.ReadOnly {
+		mode = "只读"
+	}
+	fmt.Printf("Web界面已启动: http://%s/ (用户名: %s, 前缀: %s, %s模式)\n", displayAddr(options.Listen), options.Username, options.Prefix, mode)
+	fmt.Println("按Ctrl+C停止")
+
+	select {
+	case err := <-errChan:
+		return fmt.Errorf("启动服务失败: %v", err)
+	case <-stop:
+	}
+
+	fmt.Println("正在停止服务...")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return server.Shutdown(ctx)
+}
+
+// displayAddr 将只有端口的监听地址转换为可在浏览器中打开的地址
+func displayAddr(listen string) string {
+	if strings.HasPrefix(listen, ":") {
+		return "localhost" + listen
+	}
+	return listen
+}
+
+// basicAuth 检查基本认证的用户名和密码
+func (s *webServer) basicAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		userMatch := subtle.ConstantTimeCompare([]byte(username), []byte(s.options.Username)) == 1
+		passMatch := subtle.ConstantTimeCompare([]byte(password), []byte(s.options.Password)) == 1
+		if !ok || !userMatch || !passMatch {
+			w.Header().Set("WWW-Authenticate", `Basic realm="alioss", charset="UTF-8"`)
+			http.Error(w, "需要登录", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// cleanDirPath 标准化页面请求的目录路径，去除前导斜杠并确保以斜杠结尾
+func cleanDirPath(dir string) string {
+	dir = strings.TrimPrefix(dir, "/")
+	if dir != "" && !strings.HasSuffix(dir, "/") {
+		dir += "/"
+	}
+	return dir
+}
+
+// objectKey 将请求中的相对路径转换为完整的OSS路径
+func (s *webServer) objectKey(r *http.Request) (string, error) {
+	key := strings.TrimPrefix(r.URL.Query().Get("key"), "/")
+	if key == "" || strings.HasSuffix(key, "/") {
+		return "", fmt.Errorf("无效的文件路径: %s", key)
+	}
+	return s.options.Prefix + key, nil
+}
+
+// handleIndex 显示目录下的子目录和文件
+func (s *webServer) handleIndex(w http.ResponseWriter, r *http.Request) {
+	dir := cleanDirPath(r.URL.Query().Get("path"))
+
+	dirs, objects, err := s.client.ListDir(s.options.Prefix + dir)
+	if err != nil {
+		s.fail(w, err, http.StatusBadGateway)
+		return
+	}
+
+	page := &webPage{
+		Prefix:   s.options.Prefix,
+		Path:     dir,
+		ReadOnly: s.options.ReadOnly,
+	}
+
+	// 生成面包屑导航
+	page.Crumbs = append(page.Crumbs, webEntry{Name: "/", Path: ""})
+	crumbPath := ""
+	for _, name := range strings.Split(strings.TrimSuffix(dir, "/"), "/") {
+		if name == "" {
+			continue
+		}
+		crumbPath += name + "/"
+		page.Crumbs = append(page.Crumbs, webEntry{Name: name, Path: crumbPath})
+	}
+
+	for _, d := range dirs {
+		relPath := strings.TrimPrefix(d, s.options.Prefix)
+		page.Dirs = append(page.Dirs, webEntry{
+			Name: path.Base(relPath) + "/",
+			Path: relPath,
+		})
+	}
+	for _, object := range objects {
+		relPath := strings.TrimPrefix(object.Key, s.options.Prefix)
+		page.Files = append(page.Files, webEntry{
+			Name:     path.Base(relPath),
+			Path:     relPath,
+			Size:     formatSize(object.Size),
+			Modified: object.LastModified.Local().Format("2006-01-02 15:04:05"),
+		})
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := indexTemplate.Execute(w, page); err != nil {
+		fmt.Fprintf(os.Stderr, "渲染页面失败: %v\n", err)
+	}
+}
+
+// handleDownload 通过客户端以流的方式下载文件
+func (s *webServer) handleDownload(w http.ResponseWriter, r *http.Request) {
+	key, err := s.objectKey(r)
+	if err != nil {
+		s.fail(w, err, http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.client.bucket.DoGetObject(&oss.GetObjectRequest{ObjectKey: key}, nil)
+	if err != nil {
+		if isNotFound(err) {
+			s.fail(w, fmt.Errorf("文件不存在: %s", key), http.StatusNotFound)
+			return
+		}
+		s.fail(w, fmt.Errorf("下载文件失败: %v", err), http.StatusBadGateway)
+		return
+	}
+	defer result.Response.Close()
+
+	for _, header := range []string{oss.HTTPHeaderContentType, oss.HTTPHeaderContentLength, oss.HTTPHeaderLastModified, oss.HTTPHeaderEtag} {
+		if value := result.Response.Headers.Get(header); value != "" {
+			w.Header().Set(header, value)
+		}
+	}
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(key)}))
+
+	if _, err := io.Copy(w, result.Response.Body); err != nil {
+		fmt.Fprintf(os.Stderr, "下载中断: %s - %v\n", key, err)
+	}
+}
+
+// handleSign 生成文件的签名链接
+func (s *webServer) handleSign(w http.ResponseWriter, r *http.Request) {
+	key, err := s.objectKey(r)
+	if err != nil {
+		s.fail(w, err, http.StatusBadRequest)
+		return
+	}
+
+	signedURL, err := s.client.GetSignedURL(key, s.options.URLExpire)
+	if err != nil {
+		s.fail(w, err, http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"url":     signedURL,
+		"expires": time.Now().Add(s.options.URLExpire).Format(time.RFC3339),
+	})
+}
+
+// handleUpload 将请求内容以流的方式上传到OSS
+func (s *webServer) handleUpload(w http.ResponseWriter, r *http.Request) {
+	if s.options.ReadOnly {
+		s.fail(w, fmt.Errorf("只读模式下不允许上传"), http.StatusForbidden)
+		return
+	}
+	// 要求自定义请求头，浏览器跨站请求无法在不经过预检的情况下携带，防止CSRF
+	if r.Header.Get("X-Requested-With") != "alioss" {
+		s.fail(w, fmt.Errorf("缺少请求头 X-Requested-With"), http.StatusForbidden)
+		return
+	}
+
+	key, err := s.objectKey(r)
+	if err != nil {
+		s.fail(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := s.client.UploadStream(r.Context(), r.Body, key, nil); err != nil {
+		s.fail(w, err, http.StatusBadGateway)
+		return
+	}
+
+	fmt.Printf("已上传: %s\n", key)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"key": strings.TrimPrefix(key, s.options.Prefix)})
+}
+
+// fail 输出错误信息并返回错误状态码
+func (s *webServer) fail(w http.ResponseWriter, err error, status int) {
+	fmt.Fprintf(os.Stderr, "请求失败: %v\n", err)
+	http.Error(w, err.Error(), status)
+}
+
+// formatSize 将字节数格式化为便于阅读的大小
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// indexTemplate 目录页面模板
+var indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<title>alioss - {{.Prefix}}{{.Path}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: 4px 8px; border-bottom: 1px solid #eee; }
+td.size { text-align: right; white-space: nowrap; }
+#drop { border: 2px dashed #aaa; padding: 1.5em; margin: 1em 0; text-align: center; color: #666; }
+#drop.over { border-color: #36c; color: #36c; }
+</style>
+</head>
+<body data-path="{{.Path}}">
+<h2>{{range .Crumbs}}<a href="?path={{.Path}}">{{.Name}}</a>{{if .Path}}/{{end}}{{end}}</h2>
+{{if not .ReadOnly}}
+<div id="drop">将文件拖放到这里上传，或 <input type="file" id="picker" multiple></div>
+<div id="status"></div>
+{{end}}
+<table>
+<tr><th>名称</th><th>大小</th><th>修改时间</th><th></th></tr>
+{{range .Dirs}}<tr><td><a href="?path={{.Path}}">{{.Name}}</a></td><td class="size">-</td><td></td><td></td></tr>
+{{end}}{{range .Files}}<tr><td><a href="download?key={{.Path}}">{{.Name}}</a></td><td class="size">{{.Size}}</td><td>{{.Modified}}</td><td><button data-key="{{.Path}}" onclick="sign(this.dataset.key)">签名链接</button></td></tr>
+{{end}}
+</table>
+<script>
+function sign(key) {
+	fetch('sign?key=' + encodeURIComponent(key)).then(function (resp) {
+		if (!resp.ok) { return resp.text().then(function (t) { throw new Error(t); }); }
+		return resp.json();
+	}).then(function (data) {
+		if (navigator.clipboard) { navigator.clipboard.writeText(data.url); }
+		prompt('签名链接（有效期至 ' + data.expires + '）:', data.url);
+	}).catch(function (err) { alert('生成签名链接失败: ' + err.message); });
+}
+
+function upload(files) {
+	var dir = document.body.dataset.path;
+	var status = document.getElementById('status');
+	var chain = Promise.resolve();
+	Array.prototype.forEach.call(files, function (file) {
+		chain = chain.then(function () {
+			status.textContent = '正在上传: ' + file.name;
+			return fetch('upload?key=' + encodeURIComponent(dir + file.name), {
+				method: 'PUT',
+				headers: { 'X-Requested-With': 'alioss' },
+				body: file
+			}).then(function (resp) {
+				if (!resp.ok) { return resp.text().then(function (t) { throw new Error(file.name + ': ' + t); }); }
+			});
+		});
+	});
+	chain.then(function () { location.reload(); }).catch(function (err) {
+		status.textContent = '上传失败: ' + err.message;
+	});
+}
+
+var drop = document.getElementById('drop');
+if (drop) {
+	drop.addEventListener('dragover', function (e) { e.preventDefault(); drop.className = 'over'; });
+	drop.addEventListener('dragleave', function () { drop.className = ''; });
+	drop.addEventListener('drop', function (e) { e.preventDefault(); drop.className = ''; upload(e.dataTransfer.files); });
+	document.getElementById('picker').addEventListener('change', function (e) { upload(e.target.files); });
+}
+</script>
+</body>
+</html>
+`))
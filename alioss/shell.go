@@ -0,0 +1,697 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// maxShellHistory 历史记录文件保留的最大条数
+const maxShellHistory = 1000
+
+// shellCommands 交互模式支持的命令
+var shellCommands = []string{"cd", "exit", "get", "help", "ls", "put", "pwd", "quit", "rm", "stat", "url"}
+
+// dirListing 缓存的目录列表
+type dirListing struct {
+	dirs    []string // 子目录名，以斜杠结尾
+	objects []oss.ObjectProperties
+}
+
+// ossShell 交互式命令行，维护当前远程目录和目录列表缓存
+type ossShell struct {
+	client      *OSSClient
+	cwd         string // 当前远程目录，根目录为空，其它以斜杠结尾
+	cache       map[string]*dirListing
+	historyFile string
+}
+
+// Shell 启动交互式命令行
+func (c *OSSClient) Shell() error {
+	s := &ossShell{
+		client: c,
+		cache:  make(map[string]*dirListing),
+	}
+
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		s.historyFile = filepath.Join(homeDir, ".alioss_history")
+	}
+
+	history := &shellHistory{entries: s.loadHistory(), file: s.historyFile}
+	editor := newLineEditor(history, s.complete)
+	if editor.terminal != nil {
+		fmt.Printf("已连接到Bucket: %s，输入 help 查看命令，exit 退出\n", c.config.Bucket)
+	}
+
+	for {
+		prompt := ""
+		if editor.terminal != nil {
+			prompt = fmt.Sprintf("oss://%s/%s> ", c.config.Bucket, s.cwd)
+		}
+		line, err := editor.readLine(prompt)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		args, err := splitShellArgs(line)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			continue
+		}
+
+		if args[0] == "exit" || args[0] == "quit" {
+			return nil
+		}
+		if err := s.run(args); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		}
+	}
+}
+
+// run 执行一条命令
+func (s *ossShell) run(args []string) error {
+	// 审计日志中记录为 shell put、shell rm 等
+	s.client.auditLog.command = "shell " + args[0]
+
+	switch args[0] {
+	case "help":
+		fmt.Println("ls [路径]              列出目录")
+		fmt.Println("cd [路径]              切换远程目录，不带参数时回到根目录")
+		fmt.Println("pwd                    显示当前远程目录")
+		fmt.Println("get <远程路径> [本地路径] 下载文件或目录")
+		fmt.Println("put <本地路径> [远程路径] 上传文件或目录")
+		fmt.Println("rm [-r] <远程路径>     删除文件，-r 删除目录")
+		fmt.Println("stat <远程路径>        显示文件信息")
+		fmt.Println("url <远程路径> [秒数]  生成临时访问URL")
+		fmt.Println("exit                   退出")
+	case "pwd":
+		fmt.Println("/" + s.cwd)
+	case "ls":
+		return s.ls(args[1:])
+	case "cd":
+		return s.cd(args[1:])
+	case "get":
+		return s.get(args[1:])
+	case "put":
+		return s.put(args[1:])
+	case "rm":
+		return s.rm(args[1:])
+	case "stat":
+		return s.stat(args[1:])
+	case "url":
+		return s.url(args[1:])
+	default:
+		return fmt.Errorf("未知命令: %s，输入 help 查看命令", args[0])
+	}
+	return nil
+}
+
+// resolve 将相对于当前目录的路径转换为OSS路径，支持 . 和 ..
+// 以斜杠结尾的路径和 .、.. 解析为目录
+func (s *ossShell) resolve(arg string) string {
+	isDir := arg == "" || strings.HasSuffix(arg, "/") || arg == "." || arg == ".." ||
+		strings.HasSuffix(arg, "/.") || strings.HasSuffix(arg, "/..")
+
+	full := arg
+	if !strings.HasPrefix(arg, "/") {
+		full = "/" + s.cwd + arg
+	}
+	full = strings.TrimPrefix(path.Clean(full), "/")
+
+	if isDir && full != "" {
+		full += "/"
+	}
+	return full
+}
+
+// listDir 获取目录列表，优先使用缓存
+func (s *ossShell) listDir(prefix string, refresh bool) (*dirListing, error) {
+	if listing, ok := s.cache[prefix]; ok && !refresh {
+		return listing, nil
+	}
+
+	dirs, objects, err := s.client.ListDir(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	listing := &dirListing{objects: objects}
+	for _, dir := range dirs {
+		listing.dirs = append(listing.dirs, strings.TrimPrefix(dir, prefix))
+	}
+	s.cache[prefix] = listing
+	return listing, nil
+}
+
+// invalidate 清除路径所在目录及其上级目录的缓存
+func (s *ossShell) invalidate(ossPath string) {
+	for prefix := range s.cache {
+		if strings.HasPrefix(ossPath, prefix) {
+			delete(s.cache, prefix)
+		}
+	}
+}
+
+// isDir 判断不以斜杠结尾的路径是否为目录（存在以它为前缀的文件且没有同名文件）
+func (s *ossShell) isDir(ossPath string) (bool, error) {
+	if ossPath == "" || strings.HasSuffix(ossPath, "/") {
+		return true, nil
+	}
+
+	parent := ""
+	if i := strings.LastIndex(ossPath, "/"); i >= 0 {
+		parent = ossPath[:i+1]
+	}
+	listing, err := s.listDir(parent, false)
+	if err != nil {
+		return false, err
+	}
+
+	name := strings.TrimPrefix(ossPath, parent)
+	for _, object := range listing.objects {
+		if object.Key == ossPath {
+			return false, nil
+		}
+	}
+	for _, dir := range listing.dirs {
+		if dir == name+"/" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// resolveTarget 解析命令参数，目录会补全结尾的斜杠
+func (s *ossShell) resolveTarget(arg string) (string, error) {
+	ossPath := s.resolve(arg)
+	dir, err := s.isDir(ossPath)
+	if err != nil {
+		return "", err
+	}
+	if dir && ossPath != "" && !strings.HasSuffix(ossPath, "/") {
+		ossPath += "/"
+	}
+	return ossPath, nil
+}
+
+// ls 列出目录内容
+func (s *ossShell) ls(args []string) error {
+	arg := ""
+	if len(args) > 0 {
+		arg = args[0]
+	}
+	prefix, err := s.resolveTarget(arg)
+	if err != nil {
+		return err
+	}
+
+	// 参数是文件时显示该文件
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		return s.stat([]string{arg})
+	}
+
+	listing, err := s.listDir(prefix, true)
+	if err != nil {
+		return err
+	}
+	if len(listing.dirs) == 0 && len(listing.objects) == 0 && prefix != "" {
+		return fmt.Errorf("目录不存在或为空: /%s", prefix)
+	}
+
+	for _, dir := range listing.dirs {
+		fmt.Printf("%10s  %19s  %s\n", "-", "", dir)
+	}
+	for _, object := range listing.objects {
+		fmt.Printf("%10s  %s  %s\n", formatSize(object.Size), object.LastModified.Local().Format("2006-01-02 15:04:05"), strings.TrimPrefix(object.Key, prefix))
+	}
+	return nil
+}
+
+// cd 切换当前远程目录
+func (s *ossShell) cd(args []string) error {
+	if len(args) == 0 {
+		s.cwd = ""
+		return nil
+	}
+
+	dir := s.resolve(args[0])
+	if dir != "" && !strings.HasSuffix(dir, "/") {
+		dir += "/"
+	}
+	if dir != "" {
+		listing, err := s.listDir(dir, false)
+		if err != nil {
+			return err
+		}
+		if len(listing.dirs) == 0 && len(listing.objects) == 0 {
+			return fmt.Errorf("目录不存在: /%s", dir)
+		}
+	}
+
+	s.cwd = dir
+	return nil
+}
+
+// get 下载文件或目录
+func (s *ossShell) get(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("用法: get <远程路径> [本地路径]")
+	}
+	ossPath, err := s.resolveTarget(args[0])
+	if err != nil {
+		return err
+	}
+	if ossPath == "" {
+		return fmt.Errorf("不能下载整个Bucket")
+	}
+
+	localPath := "."
+	if len(args) > 1 {
+		localPath = args[1]
+	} else if strings.HasSuffix(ossPath, "/") {
+		localPath = path.Base(ossPath)
+	}
+
+	ctx, stop := withInterrupt(context.Background())
+	defer stop()
+	return s.client.DownloadFile(ctx, ossPath, localPath, &DownloadOptions{})
+}
+
+// put 上传文件或目录，未指定远程路径时上传到当前目录
+func (s *ossShell) put(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("用法: put <本地路径> [远程路径]")
+	}
+	localPath := args[0]
+	info, err := os.Stat(localPath)
+	if err != nil {
+		return fmt.Errorf("读取文件信息失败: %v", err)
+	}
+
+	var ossPath string
+	if len(args) > 1 {
+		ossPath, err = s.resolveTarget(args[1])
+		if err != nil {
+			return err
+		}
+		if strings.HasSuffix(ossPath, "/") && !info.IsDir() {
+			ossPath += filepath.Base(localPath)
+		}
+	} else {
+		absPath, err := filepath.Abs(localPath)
+		if err != nil {
+			return fmt.Errorf("获取绝对路径失败: %v", err)
+		}
+		ossPath = s.cwd + filepath.Base(absPath)
+		if info.IsDir() {
+			ossPath += "/"
+		}
+	}
+
+	defer s.invalidate(ossPath)
+	ctx, stop := withInterrupt(context.Background())
+	defer stop()
+	if err := s.client.UploadFile(ctx, localPath, ossPath, &UploadOptions{}); err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		fmt.Printf("已上传: %s\n", ossPath)
+	}
+	return nil
+}
+
+// rm 删除文件，删除目录时需要 -r
+func (s *ossShell) rm(args []string) error {
+	recursive := false
+	if len(args) > 0 && args[0] == "-r" {
+		recursive = true
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("用法: rm [-r] <远程路径>")
+	}
+
+	for _, arg := range args {
+		ossPath, err := s.resolveTarget(arg)
+		if err != nil {
+			return err
+		}
+		if ossPath == "" {
+			return fmt.Errorf("不能删除整个Bucket")
+		}
+		if strings.HasSuffix(ossPath, "/") && !recursive {
+			return fmt.Errorf("%s 是目录，使用 rm -r 删除", arg)
+		}
+
+		err = s.client.Remove(ossPath)
+		s.invalidate(ossPath)
+		if err != nil {
+			return err
+		}
+		if !strings.HasSuffix(ossPath, "/") {
+			fmt.Printf("已删除: %s\n", ossPath)
+		}
+	}
+	return nil
+}
+
+// stat 显示文件信息
+func (s *ossShell) stat(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("用法: stat <远程路径>")
+	}
+	ossPath := s.resolve(args[0])
+	if ossPath == "" || strings.HasSuffix(ossPath, "/") {
+		return fmt.Errorf("%s 是目录", args[0])
+	}
+
+	meta, err := s.client.bucket.GetObjectDetailedMeta(ossPath)
+	if err != nil {
+		return fmt.Errorf("获取文件信息失败: %v", err)
+	}
+
+	fmt.Printf("路径: %s\n", ossPath)
+	fields := []struct{ name, header string }{
+		{"大小", oss.HTTPHeaderContentLength},
+		{"类型", oss.HTTPHeaderContentType},
+		{"修改时间", oss.HTTPHeaderLastModified},
+		{"ETag", oss.HTTPHeaderEtag},
+		{"CRC64", oss.HTTPHeaderOssCRC64},
+		{"存储类型", oss.HTTPHeaderOssStorageClass},
+		{"对象类型", "X-Oss-Object-Type"},
+		{"版本ID", "X-Oss-Version-Id"},
+	}
+	for _, field := range fields {
+		if value := meta.Get(field.header); value != "" {
+			fmt.Printf("%s: %s\n", field.name, value)
+		}
+	}
+
+	// 输出自定义元数据
+	var metaKeys []string
+	for key := range meta {
+		if strings.HasPrefix(key, oss.HTTPHeaderOssMetaPrefix) {
+			metaKeys = append(metaKeys, key)
+		}
+	}
+	sort.Strings(metaKeys)
+	for _, key := range metaKeys {
+		fmt.Printf("%s: %s\n", strings.ToLower(key), meta.Get(key))
+	}
+	return nil
+}
+
+// url 生成临时访问URL
+func (s *ossShell) url(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("用法: url <远程路径> [秒数]")
+	}
+	expireTime := 3600 * time.Second
+	if len(args) > 1 {
+		expireSeconds := 0
+		if _, err := fmt.Sscanf(args[1], "%d", &expireSeconds); err != nil || expireSeconds <= 0 {
+			return fmt.Errorf("无效的过期时间: %s", args[1])
+		}
+		expireTime = time.Duration(expireSeconds) * time.Second
+	}
+
+	signedURL, err := s.client.GetSignedURL(s.resolve(args[0]), expireTime)
+	if err != nil {
+		return err
+	}
+	fmt.Println(signedURL)
+	return nil
+}
+
+// complete Tab补全：第一个词补全命令，put的第一个参数补全本地路径，其它参数补全远程路径
+func (s *ossShell) complete(before string) (string, []string) {
+	// 找到当前正在输入的词（未转义的空格之后的部分）
+	start := 0
+	for i := 0; i < len(before); i++ {
+		if before[i] == '\\' {
+			i++
+			continue
+		}
+		if before[i] == ' ' {
+			start = i + 1
+		}
+	}
+	word := before[start:]
+	words, _ := splitShellArgs(before[:start])
+
+	var candidates []string
+	var err error
+	switch {
+	case len(words) == 0:
+		for _, command := range shellCommands {
+			if strings.HasPrefix(command, word) {
+				candidates = append(candidates, command+" ")
+			}
+		}
+	case words[0] == "put" && len(words) == 1:
+		candidates, err = completeLocal(unescapeShellWord(word))
+	default:
+		candidates, err = s.completeRemote(unescapeShellWord(word))
+	}
+	if err != nil || len(candidates) == 0 {
+		return before, nil
+	}
+
+	// 用所有候选的公共前缀替换当前词
+	common := candidates[0]
+	for _, candidate := range candidates[1:] {
+		for !strings.HasPrefix(candidate, common) {
+			common = common[:len(common)-1]
+		}
+	}
+	// 按字节截取公共前缀时可能截断多字节字符
+	for !utf8.ValidString(common) {
+		common = common[:len(common)-1]
+	}
+	if len(words) > 0 {
+		common = escapeShellWord(common)
+		if len(candidates) == 1 && !strings.HasSuffix(common, "/") {
+			common += " "
+		}
+	}
+
+	// 列出候选时只显示名称部分
+	display := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		display[i] = candidate[strings.LastIndex(strings.TrimSuffix(candidate, "/"), "/")+1:]
+	}
+	return before[:start] + common, display
+}
+
+// completeRemote 根据缓存的目录列表补全远程路径
+func (s *ossShell) completeRemote(word string) ([]string, error) {
+	dirPart := ""
+	if i := strings.LastIndex(word, "/"); i >= 0 {
+		dirPart = word[:i+1]
+	}
+	name := strings.TrimPrefix(word, dirPart)
+
+	prefix := s.resolve(dirPart)
+	listing, err := s.listDir(prefix, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []string
+	for _, dir := range listing.dirs {
+		if strings.HasPrefix(dir, name) {
+			candidates = append(candidates, dirPart+dir)
+		}
+	}
+	for _, object := range listing.objects {
+		base := strings.TrimPrefix(object.Key, prefix)
+		if strings.HasPrefix(base, name) {
+			candidates = append(candidates, dirPart+base)
+		}
+	}
+	sort.Strings(candidates)
+	return candidates, nil
+}
+
+// completeLocal 补全本地路径
+func completeLocal(word string) ([]string, error) {
+	dirPart := ""
+	if i := strings.LastIndexAny(word, "/"+string(filepath.Separator)); i >= 0 {
+		dirPart = word[:i+1]
+	}
+	name := strings.TrimPrefix(word, dirPart)
+
+	dir := dirPart
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []string
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), name) {
+			continue
+		}
+		candidate := dirPart + entry.Name()
+		if entry.IsDir() {
+			candidate += "/"
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates, nil
+}
+
+// splitShellArgs 按空格拆分命令行，支持引号和反斜杠转义
+func splitShellArgs(line string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inWord := false
+	var quote rune
+
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\\' && i+1 < len(runes):
+			i++
+			current.WriteRune(runes[i])
+			inWord = true
+		case r == '"' || r == '\'':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				args = append(args, current.String())
+				current.Reset()
+				inWord = false
+			}
+		default:
+			current.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("引号不匹配")
+	}
+	if inWord {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// escapeShellWord 转义补全结果中的空格、引号和反斜杠
+func escapeShellWord(word string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, " ", `\ `, `"`, `\"`, `'`, `\'`)
+	return replacer.Replace(word)
+}
+
+// unescapeShellWord 去除正在输入的词中的转义
+func unescapeShellWord(word string) string {
+	args, err := splitShellArgs(word)
+	if err != nil || len(args) == 0 {
+		return word
+	}
+	return args[0]
+}
+
+// loadHistory 读取历史记录
+func (s *ossShell) loadHistory() []string {
+	if s.historyFile == "" {
+		return nil
+	}
+	file, err := os.Open(s.historyFile)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var history []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			history = append(history, line)
+		}
+	}
+	if len(history) > maxShellHistory {
+		history = history[len(history)-maxShellHistory:]
+		s.rewriteHistory(history)
+	}
+	return history
+}
+
+// rewriteHistory 历史记录过多时只保留最近的部分
+func (s *ossShell) rewriteHistory(history []string) {
+	data := strings.Join(history, "\n") + "\n"
+	if err := os.WriteFile(s.historyFile, []byte(data), 0600); err != nil {
+		fmt.Fprintf(os.Stderr, "警告: 保存历史记录失败: %v\n", err)
+	}
+}
+
+// shellHistory 交互模式的历史记录，实现term.History，新的命令同时追加到历史记录文件
+type shellHistory struct {
+	entries []string
+	file    string
+}
+
+// Add 添加一条历史记录，忽略空行和与上一条相同的命令
+func (h *shellHistory) Add(entry string) {
+	entry = strings.TrimSpace(entry)
+	if entry == "" || (len(h.entries) > 0 && h.entries[len(h.entries)-1] == entry) {
+		return
+	}
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > maxShellHistory {
+		h.entries = h.entries[len(h.entries)-maxShellHistory:]
+	}
+	h.append(entry)
+}
+
+// Len 历史记录条数
+func (h *shellHistory) Len() int {
+	return len(h.entries)
+}
+
+// At 获取历史记录，0为最近的一条
+func (h *shellHistory) At(idx int) string {
+	return h.entries[len(h.entries)-1-idx]
+}
+
+// append 将一条命令追加到历史记录文件
+func (h *shellHistory) append(line string) {
+	if h.file == "" {
+		return
+	}
+	file, err := os.OpenFile(h.file, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	fmt.Fprintln(file, line)
+}
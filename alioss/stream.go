@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+const (
+	// defaultStreamPartSize 流式上传默认分片大小（8MB）
+	defaultStreamPartSize int64 = 8 * 1024 * 1024
+	// minStreamPartSize OSS要求除最后一片外每个分片至少100KB
+	minStreamPartSize int64 = 100 * 1024
+)
+
+// CatFile 将OSS文件内容以流的方式写入w，rangeSpec为空时读取整个文件
+// rangeSpec格式同HTTP Range: "start-end"、"start-" 或 "-suffixLength"
+func (c *OSSClient) CatFile(ossPath string, w io.Writer, rangeSpec string) error {
+	// 标准化OSS路径，去除前导斜杠
+	ossPath = strings.TrimPrefix(ossPath, "/")
+
+	var ossOptions []oss.Option
+	if rangeSpec != "" {
+		if err := validateRange(rangeSpec); err != nil {
+			return err
+		}
+		ossOptions = append(ossOptions, oss.NormalizedRange(rangeSpec))
+	}
+
+	body, err := c.bucket.GetObject(ossPath, ossOptions...)
+	if err != nil {
+		return fmt.Errorf("读取文件失败: %v", err)
+	}
+	defer body.Close()
+
+	if _, err := io.Copy(w, body); err != nil {
+		return fmt.Errorf("输出文件内容失败: %v", err)
+	}
+
+	return nil
+}
+
+// validateRange 检查范围参数格式是否正确
+func validateRange(rangeSpec string) error {
+	parts := strings.SplitN(rangeSpec, "-", 2)
+	if len(parts) != 2 || (parts[0] == "" && parts[1] == "") {
+		return fmt.Errorf("无效的范围: %s，格式应为 start-end、start- 或 -length", rangeSpec)
+	}
+
+	var start, end int64
+	if parts[0] != "" {
+		if _, err := fmt.Sscanf(parts[0], "%d", &start); err != nil || start < 0 {
+			return fmt.Errorf("无效的范围起始位置: %s", parts[0])
+		}
+	}
+	if parts[1] != "" {
+		if _, err := fmt.Sscanf(parts[1], "%d", &end); err != nil || end < 0 {
+			return fmt.Errorf("无效的范围结束位置: %s", parts[1])
+		}
+	}
+	if parts[0] != "" && parts[1] != "" && end < start {
+		return fmt.Errorf("无效的范围: 结束位置小于起始位置")
+	}
+
+	return nil
+}
+
+// UploadStream 从reader读取数据并上传到OSS，适用于长度未知的流（如标准输入）
+// 数据不足一个分片时使用普通上传，否则使用分片上传
+func (c *OSSClient) UploadStream(ctx context.Context, reader io.Reader, ossPath string, options *UploadOptions) error {
+	if ossPath == "" {
+		return fmt.Errorf("从流上传时必须指定OSS路径")
+	}
+
+	// 标准化OSS路径，去除前导斜杠
+	ossPath = strings.TrimPrefix(ossPath, "/")
+
+	total, err := c.uploadStream(ctx, reader, ossPath, options)
+	c.auditContext(ctx, AuditEntry{Op: AuditPut, Keys: []string{ossPath}, Bytes: total, Detail: "stream"}, err)
+	return err
+}
+
+// uploadStream 执行流式上传，返回已读取的字节数
+func (c *OSSClient) uploadStream(ctx context.Context, reader io.Reader, ossPath string, options *UploadOptions) (int64, error) {
+	partSize := defaultStreamPartSize
+	if options != nil && options.PartSize > 0 {
+		partSize = options.PartSize
+	}
+	if partSize < minStreamPartSize {
+		partSize = minStreamPartSize
+	}
+
+	buf := make([]byte, partSize)
+
+	// 先读取第一个分片，判断是否需要分片上传
+	n, err := io.ReadFull(reader, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return int64(n), fmt.Errorf("读取输入失败: %v", err)
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		putOptions := append(uploadTagOptions(options), oss.WithContext(ctx))
+		if err := c.bucket.PutObject(ossPath, bytes.NewReader(buf[:n]), putOptions...); err != nil {
+			return int64(n), fmt.Errorf("上传文件失败: %v", err)
+		}
+		fmt.Fprintf(os.Stderr, "已上传: %s (%d 字节)\n", ossPath, n)
+		return int64(n), nil
+	}
+
+	imur, err := c.bucket.InitiateMultipartUpload(ossPath, uploadTagOptions(options)...)
+	if err != nil {
+		return 0, fmt.Errorf("初始化分片上传失败: %v", err)
+	}
+
+	var parts []oss.UploadPart
+	var total int64
+	partNumber := 1
+
+	for n > 0 {
+		// 中断时取消分片上传，不留下未完成的分片
+		if err := ctx.Err(); err != nil {
+			c.bucket.AbortMultipartUpload(imur)
+			return total, fmt.Errorf("上传已中断: %v", err)
+		}
+		part, err := c.bucket.UploadPart(imur, bytes.NewReader(buf[:n]), int64(n), partNumber, oss.WithContext(ctx))
+		if err != nil {
+			c.bucket.AbortMultipartUpload(imur)
+			return total, fmt.Errorf("上传分片 %d 失败: %v", partNumber, err)
+		}
+		parts = append(parts, part)
+		total += int64(n)
+		fmt.Fprintf(os.Stderr, "已上传分片 %d (累计 %d 字节)\n", partNumber, total)
+		partNumber++
+
+		n, err = io.ReadFull(reader, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			c.bucket.AbortMultipartUpload(imur)
+			return total, fmt.Errorf("读取输入失败: %v", err)
+		}
+	}
+
+	if _, err := c.bucket.CompleteMultipartUpload(imur, parts); err != nil {
+		c.bucket.AbortMultipartUpload(imur)
+		return total, fmt.Errorf("完成分片上传失败: %v", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "已上传: %s (%d 个分片, %d 字节)\n", ossPath, len(parts), total)
+	return total, nil
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// TagFilter 按对象标签筛选文件的条件
+type TagFilter struct {
+	Key      string
+	Value    string
+	AnyValue bool // 只要求存在该标签，不限制值
+}
+
+// parseTags 解析 k=v 形式的标签，每个参数可以用逗号分隔多个标签
+func parseTags(specs []string) ([]oss.Tag, error) {
+	var tags []oss.Tag
+	for _, spec := range specs {
+		for _, pair := range strings.Split(spec, ",") {
+			pair = strings.TrimSpace(pair)
+			if pair == "" {
+				continue
+			}
+			key, value, ok := strings.Cut(pair, "=")
+			if !ok || key == "" {
+				return nil, fmt.Errorf("无效的标签: %s，格式应为 键=值", pair)
+			}
+			tags = append(tags, oss.Tag{Key: key, Value: value})
+		}
+	}
+	return tags, nil
+}
+
+// parseTagFilters 解析标签筛选条件，k=v 要求标签值相等，只有 k 时要求存在该标签
+func parseTagFilters(specs []string) ([]TagFilter, error) {
+	var filters []TagFilter
+	for _, spec := range specs {
+		for _, pair := range strings.Split(spec, ",") {
+			pair = strings.TrimSpace(pair)
+			if pair == "" {
+				continue
+			}
+			key, value, ok := strings.Cut(pair, "=")
+			if key == "" {
+				return nil, fmt.Errorf("无效的标签条件: %s，格式应为 键=值 或 键", pair)
+			}
+			filters = append(filters, TagFilter{Key: key, Value: value, AnyValue: !ok})
+		}
+	}
+	return filters, nil
+}
+
+// matchTags 判断标签是否满足所有筛选条件
+func matchTags(tags map[string]string, filters []TagFilter) bool {
+	for _, filter := range filters {
+		value, ok := tags[filter.Key]
+		if !ok || (!filter.AnyValue && value != filter.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// formatTags 按键排序输出标签
+func formatTags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + "=" + tags[key]
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// uploadTagOptions 上传时设置对象标签的选项
+func uploadTagOptions(options *UploadOptions) []oss.Option {
+	if options == nil || len(options.Tags) == 0 {
+		return nil
+	}
+	return []oss.Option{oss.SetTagging(oss.Tagging{Tags: options.Tags})}
+}
+
+// GetTags 获取文件的标签
+func (c *OSSClient) GetTags(ossPath string) (map[string]string, error) {
+	// 标准化OSS路径，去除前导斜杠
+	ossPath = strings.TrimPrefix(ossPath, "/")
+
+	result, err := c.bucket.GetObjectTagging(ossPath)
+	if err != nil {
+		return nil, fmt.Errorf("获取标签失败: %v", err)
+	}
+
+	tags := make(map[string]string, len(result.Tags))
+	for _, tag := range result.Tags {
+		tags[tag.Key] = tag.Value
+	}
+	return tags, nil
+}
+
+// putTags 设置文件的全部标签，没有标签时删除标签
+func (c *OSSClient) putTags(ossPath string, tags map[string]string) error {
+	if len(tags) == 0 {
+		err := c.bucket.DeleteObjectTagging(ossPath)
+		c.audit(AuditEntry{Op: AuditTag, Keys: []string{ossPath}, Detail: "(无标签)"}, err)
+		if err != nil {
+			return fmt.Errorf("删除标签失败: %v", err)
+		}
+		return nil
+	}
+
+	tagging := oss.Tagging{}
+	for key, value := range tags {
+		tagging.Tags = append(tagging.Tags, oss.Tag{Key: key, Value: value})
+	}
+	sort.Slice(tagging.Tags, func(i, j int) bool { return tagging.Tags[i].Key < tagging.Tags[j].Key })
+
+	err := c.bucket.PutObjectTagging(ossPath, tagging)
+	c.audit(AuditEntry{Op: AuditTag, Keys: []string{ossPath}, Detail: formatTags(tags)}, err)
+	if err != nil {
+		return fmt.Errorf("设置标签失败: %v", err)
+	}
+	return nil
+}
+
+// SetTags 为文件添加标签，已有的同名标签会被覆盖，其它标签保留
+func (c *OSSClient) SetTags(ossPath string, tags []oss.Tag) error {
+	ossPath = strings.TrimPrefix(ossPath, "/")
+
+	current, err := c.GetTags(ossPath)
+	if err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		current[tag.Key] = tag.Value
+	}
+	return c.putTags(ossPath, current)
+}
+
+// RemoveTags 删除文件的指定标签，keys为空时删除所有标签
+func (c *OSSClient) RemoveTags(ossPath string, keys []string) error {
+	ossPath = strings.TrimPrefix(ossPath, "/")
+
+	if len(keys) == 0 {
+		return c.putTags(ossPath, nil)
+	}
+
+	current, err := c.GetTags(ossPath)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		delete(current, key)
+	}
+	return c.putTags(ossPath, current)
+}
+
+// tagFilterBatchSize 边列举边按标签筛选时每批获取标签的文件数
+const tagFilterBatchSize = 100
+
+// forEachKey 通过传输调度器对每个文件执行fn，可重试的错误会重试，返回失败的文件数
+func forEachKey(verb string, keys []string, workerCount int, fn func(key string) error) int {
+	scheduler := newTransferScheduler(context.Background(), verb, workerCount, 0, func(event TransferEvent) {
+		switch event.Status {
+		case TransferFailed:
+			fmt.Fprintf(os.Stderr, "%s: %v\n", event.Name, event.Err)
+		case TransferRetry:
+			printTransferEvent(verb, event)
+		}
+	})
+	for _, key := range keys {
+		scheduler.submit(&transferTask{
+			name: key,
+			run: func(ctx context.Context) (string, error) {
+				if err := fn(key); err != nil {
+					return "", err
+				}
+				return key, nil
+			},
+		})
+	}
+	return scheduler.wait().Failed
+}
+
+// FetchTags 并发获取多个文件的标签，部分文件失败时仍返回已获取的结果
+func (c *OSSClient) FetchTags(keys []string, workerCount int) (map[string]map[string]string, error) {
+	var mu sync.Mutex
+	result := make(map[string]map[string]string, len(keys))
+
+	failCount := forEachKey("获取标签", keys, workerCount, func(key string) error {
+		tags, err := c.GetTags(key)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		result[key] = tags
+		mu.Unlock()
+		return nil
+	})
+	if failCount > 0 {
+		return result, fmt.Errorf("%d 个文件获取标签失败", failCount)
+	}
+
+	return result, nil
+}
+
+// FilterByTags 从文件列表中筛选出标签满足条件的文件，保持原有顺序
+func (c *OSSClient) FilterByTags(keys []string, filters []TagFilter, workerCount int) ([]string, error) {
+	if len(filters) == 0 {
+		return keys, nil
+	}
+
+	allTags, err := c.FetchTags(keys, workerCount)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []string
+	for _, key := range keys {
+		if matchTags(allTags[key], filters) {
+			matched = append(matched, key)
+		}
+	}
+	return matched, nil
+}
+
+// tagTargets 获取标签命令操作的文件，以斜杠结尾时为前缀下的所有文件
+func (c *OSSClient) tagTargets(target string) ([]string, error) {
+	target = strings.TrimPrefix(target, "/")
+	if target != "" && !strings.HasSuffix(target, "/") {
+		return []string{target}, nil
+	}
+
+	files, err := c.ListFiles(target)
+	if err != nil {
+		return nil, err
+	}
+
+	// 跳过目录占位对象
+	var keys []string
+	for _, file := range files {
+		if !strings.HasSuffix(file, "/") {
+			keys = append(keys, file)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("未找到匹配的文件")
+	}
+	return keys, nil
+}
+
+// tagCommand 处理 tag 子命令
+func tagCommand(client *OSSClient, p *parsedArgs) error {
+	action := p.arg(0)
+	target := p.arg(1)
+	workerCount := p.int("workers", 10)
+	params := p.args[2:]
+
+	keys, err := client.tagTargets(target)
+	if err != nil {
+		return err
+	}
+
+	var failCount int
+	switch action {
+	case "get":
+		allTags, err := client.FetchTags(keys, workerCount)
+		for _, key := range keys {
+			tags, ok := allTags[key]
+			if !ok {
+				continue
+			}
+			if len(tags) == 0 {
+				fmt.Printf("%s: (无标签)\n", key)
+			} else {
+				fmt.Printf("%s: %s\n", key, formatTags(tags))
+			}
+		}
+		return err
+
+	case "set":
+		tags, err := parseTags(params)
+		if err != nil {
+			return err
+		}
+		if len(tags) == 0 {
+			return fmt.Errorf("请提供要设置的标签，格式为 键=值")
+		}
+		failCount = forEachKey("设置标签", keys, workerCount, func(key string) error {
+			if err := client.SetTags(key, tags); err != nil {
+				return err
+			}
+			fmt.Printf("已设置标签: %s\n", key)
+			return nil
+		})
+
+	case "rm":
+		failCount = forEachKey("删除标签", keys, workerCount, func(key string) error {
+			if err := client.RemoveTags(key, params); err != nil {
+				return err
+			}
+			fmt.Printf("已删除标签: %s\n", key)
+			return nil
+		})
+
+	default:
+		return fmt.Errorf("未知的标签操作: %s，可选 set、get、rm", action)
+	}
+
+	if failCount > 0 {
+		return fmt.Errorf("%d/%d 个文件操作失败", failCount, len(keys))
+	}
+	return nil
+}
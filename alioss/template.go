@@ -0,0 +1,378 @@
+package main
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// casTemplate --cas 使用的内容寻址路径模板
+const casTemplate = "cas/{sha256:2}/{sha256}{ext}"
+
+// templatePart 路径模板的一段，name为空时是普通文本
+type templatePart struct {
+	text string
+	name string
+	arg  string
+}
+
+// pathTemplate 解析后的路径模板，如 releases/{date:2006-01-02}/{hostname}/{basename}
+type pathTemplate struct {
+	parts []templatePart
+}
+
+// templateVars 展开模板时用到的值，同一次上传的所有文件使用相同的时间
+type templateVars struct {
+	now      time.Time
+	hostname string
+	user     string
+}
+
+// templateFile 展开模板的单个文件
+type templateFile struct {
+	localPath string
+	relPath   string // 相对于上传目录的路径（使用正斜杠）
+	sha256    string
+	md5       string
+}
+
+// UploadedFile 按模板上传的一个文件的结果
+type UploadedFile struct {
+	Local  string `json:"local"`
+	Key    string `json:"key"`
+	URL    string `json:"url"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256,omitempty"`
+	Status string `json:"status"` // uploaded: 已上传，exists: 内容寻址时已存在，unchanged: 增量上传时无变化
+}
+
+// parsePathTemplate 解析路径模板，检查变量名和参数，以斜杠结尾时自动追加 {relpath}
+func parsePathTemplate(template string) (*pathTemplate, error) {
+	if strings.HasSuffix(template, "/") {
+		template += "{relpath}"
+	}
+
+	t := &pathTemplate{}
+	rest := template
+	for rest != "" {
+		start := strings.Index(rest, "{")
+		if start < 0 {
+			t.parts = append(t.parts, templatePart{text: rest})
+			break
+		}
+		if start > 0 {
+			t.parts = append(t.parts, templatePart{text: rest[:start]})
+		}
+		end := strings.Index(rest[start:], "}")
+		if end < 0 {
+			return nil, fmt.Errorf("路径模板中的 { 没有对应的 }: %s", template)
+		}
+
+		name, arg, _ := strings.Cut(rest[start+1:start+end], ":")
+		switch name {
+		case "basename", "name", "ext", "relpath", "dir", "hostname", "user", "timestamp":
+			if arg != "" {
+				return nil, fmt.Errorf("模板变量 {%s} 不接受参数", name)
+			}
+		case "date":
+			if arg == "" {
+				arg = "2006-01-02"
+			}
+		case "sha256", "md5":
+			if arg != "" {
+				if n, err := strconv.Atoi(arg); err != nil || n <= 0 {
+					return nil, fmt.Errorf("无效的模板变量: {%s:%s}，参数应为正整数", name, arg)
+				}
+			}
+		case "env":
+			if arg == "" {
+				return nil, fmt.Errorf("模板变量 {env} 需要指定环境变量名，如 {env:CI_COMMIT_SHA}")
+			}
+		default:
+			return nil, fmt.Errorf("未知的模板变量: {%s}", name)
+		}
+
+		t.parts = append(t.parts, templatePart{name: name, arg: arg})
+		rest = rest[start+end+1:]
+	}
+	return t, nil
+}
+
+// uses 判断模板是否使用了某个变量
+func (t *pathTemplate) uses(name string) bool {
+	for _, part := range t.parts {
+		if part.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// contentAddressed 模板包含内容哈希时，相同路径意味着相同内容
+func (t *pathTemplate) contentAddressed() bool {
+	return t.uses("sha256") || t.uses("md5")
+}
+
+// expand 为文件展开模板，得到OSS路径
+func (t *pathTemplate) expand(vars *templateVars, file *templateFile) (string, error) {
+	var b strings.Builder
+	for _, part := range t.parts {
+		if part.name == "" {
+			b.WriteString(part.text)
+			continue
+		}
+
+		base := path.Base(file.relPath)
+		ext := fileExt(base)
+		switch part.name {
+		case "basename":
+			b.WriteString(base)
+		case "name":
+			b.WriteString(strings.TrimSuffix(base, ext))
+		case "ext":
+			b.WriteString(ext)
+		case "relpath":
+			b.WriteString(file.relPath)
+		case "dir":
+			if dir := path.Dir(file.relPath); dir != "." {
+				b.WriteString(dir)
+			}
+		case "hostname":
+			b.WriteString(vars.hostname)
+		case "user":
+			b.WriteString(vars.user)
+		case "timestamp":
+			b.WriteString(strconv.FormatInt(vars.now.Unix(), 10))
+		case "date":
+			b.WriteString(vars.now.Format(part.arg))
+		case "sha256", "md5":
+			sum := file.sha256
+			if part.name == "md5" {
+				sum = file.md5
+			}
+			if n, _ := strconv.Atoi(part.arg); n > 0 && n < len(sum) {
+				sum = sum[:n]
+			}
+			b.WriteString(sum)
+		case "env":
+			value, ok := os.LookupEnv(part.arg)
+			if !ok {
+				return "", fmt.Errorf("环境变量 %s 未设置", part.arg)
+			}
+			b.WriteString(value)
+		}
+	}
+
+	// 变量为空时可能出现多余的斜杠
+	key := strings.TrimPrefix(path.Clean("/"+b.String()), "/")
+	if key == "" {
+		return "", fmt.Errorf("文件 %s 展开后的路径为空", file.localPath)
+	}
+	return key, nil
+}
+
+// fileExt 获取文件扩展名，.tar.gz 等打包格式作为一个整体
+func fileExt(name string) string {
+	ext := path.Ext(name)
+	if strings.HasSuffix(strings.TrimSuffix(name, ext), ".tar") {
+		ext = ".tar" + ext
+	}
+	return ext
+}
+
+// hashFile 计算文件的SHA256和MD5
+func hashFile(localPath string) (string, string, error) {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return "", "", err
+	}
+	defer file.Close()
+
+	sha256Hash := sha256.New()
+	md5Hash := md5.New()
+	if _, err := io.Copy(io.MultiWriter(sha256Hash, md5Hash), file); err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(sha256Hash.Sum(nil)), hex.EncodeToString(md5Hash.Sum(nil)), nil
+}
+
+// GetObjectURL 获取文件的访问URL（不带签名，私有Bucket需要使用GetSignedURL）
+func (c *OSSClient) GetObjectURL(ossPath string) string {
+	endpoint := c.config.EndPoint
+	scheme := "https"
+	if strings.HasPrefix(endpoint, "http://") {
+		scheme = "http"
+	}
+	endpoint = strings.TrimPrefix(strings.TrimPrefix(endpoint, "https://"), "http://")
+
+	segments := strings.Split(strings.TrimPrefix(ossPath, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return scheme + "://" + c.config.Bucket + "." + strings.TrimSuffix(endpoint, "/") + "/" + strings.Join(segments, "/")
+}
+
+// UploadTemplate 按路径模板上传文件或目录中的文件，返回每个文件对应的OSS路径
+// 模板包含内容哈希时为内容寻址上传，OSS上已存在的文件不再上传
+// signExpire大于0时返回签名URL
+func (c *OSSClient) UploadTemplate(ctx context.Context, localPath, template string, options *UploadOptions, signExpire time.Duration) ([]UploadedFile, error) {
+	t, err := parsePathTemplate(template)
+	if err != nil {
+		return nil, err
+	}
+	if options == nil {
+		options = &UploadOptions{}
+	}
+
+	vars := &templateVars{now: time.Now(), user: currentUser()}
+	vars.hostname, _ = os.Hostname()
+
+	info, err := os.Stat(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件信息失败: %v", err)
+	}
+
+	// 收集要上传的文件，模板中使用链接目标的内容，不支持以链接对象保存
+	walkOptions := *options
+	if walkOptions.Symlinks == SymlinkStore {
+		walkOptions.Symlinks = SymlinkFollow
+	}
+	var files []*localFile
+	if info.IsDir() {
+		_, err = walkLocalDir(localPath, &walkOptions, func(file *localFile) error {
+			files = append(files, file)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("遍历目录失败: %v", err)
+		}
+	} else {
+		files = append(files, &localFile{path: localPath, relPath: filepath.Base(localPath), info: info})
+	}
+
+	// 先展开所有路径，有冲突时不上传任何文件
+	needHash := t.contentAddressed()
+	results := make([]UploadedFile, len(files))
+	owners := make(map[string]int)
+	for i, file := range files {
+		tf := &templateFile{localPath: file.path, relPath: file.relPath}
+		if needHash {
+			if tf.sha256, tf.md5, err = hashFile(file.path); err != nil {
+				return nil, fmt.Errorf("计算文件 %s 的哈希失败: %v", file.path, err)
+			}
+		}
+
+		key, err := t.expand(vars, tf)
+		if err != nil {
+			return nil, err
+		}
+		if owner, ok := owners[key]; ok && (!needHash || results[owner].SHA256 != tf.sha256) {
+			return nil, fmt.Errorf("%s 和 %s 对应同一个路径: %s", results[owner].Local, file.path, key)
+		}
+		if _, ok := owners[key]; !ok {
+			owners[key] = i
+		}
+		results[i] = UploadedFile{Local: file.path, Key: key, Size: file.info.Size(), SHA256: tf.sha256}
+	}
+
+	workerCount := 1
+	if options.Concurrent {
+		workerCount = options.WorkerCount
+	}
+
+	// 每个任务只修改自己的结果，上传结果最后统一输出，这里只输出失败和进度
+	scheduler := newTransferScheduler(ctx, "上传", workerCount, options.Retries, func(event TransferEvent) {
+		if event.Status != TransferDone {
+			printTransferEvent("上传", event)
+		}
+	})
+	for i := range files {
+		result := &results[i]
+		scheduler.submit(&transferTask{
+			name: result.Local,
+			size: result.Size,
+			run: func(ctx context.Context) (string, error) {
+				status := "uploaded"
+				switch {
+				case owners[result.Key] != i:
+					// 内容相同的重复文件只上传一次
+					status = "exists"
+				case needHash:
+					exist, err := c.bucket.IsObjectExist(result.Key)
+					if err != nil {
+						return "", fmt.Errorf("检查文件是否存在失败: %v", err)
+					}
+					if exist {
+						status = "exists"
+					}
+				case options.Incremental:
+					needUpload, err := c.needUploadLocal(files[i], result.Key)
+					if err != nil {
+						return "", fmt.Errorf("检查文件是否需要上传失败: %v", err)
+					}
+					if !needUpload {
+						status = "unchanged"
+					}
+				}
+
+				if status == "uploaded" {
+					if err := c.putLocalFile(ctx, files[i], result.Key, options); err != nil {
+						return "", err
+					}
+				} else if err := options.Manifest.addLocal(files[i], result.Key); err != nil {
+					return "", err
+				}
+
+				result.URL = c.GetObjectURL(result.Key)
+				if signExpire > 0 {
+					signed, err := c.GetSignedURL(result.Key, signExpire)
+					if err != nil {
+						return "", err
+					}
+					result.URL = signed
+				}
+				result.Status = status
+				if status != "uploaded" {
+					return "", nil
+				}
+				return result.Key, nil
+			},
+		})
+	}
+	if err := scheduler.wait().err("上传"); err != nil {
+		return results, err
+	}
+
+	return results, nil
+}
+
+// printUploadMapping 输出本地文件与OSS路径的对应关系
+func printUploadMapping(results []UploadedFile, asJSON bool) error {
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	}
+
+	labels := map[string]string{"uploaded": "已上传", "exists": "已存在", "unchanged": "无变化"}
+	for _, result := range results {
+		if result.Status == "" {
+			fmt.Printf("%s -> %s (失败)\n", result.Local, result.Key)
+			continue
+		}
+		fmt.Printf("%s -> %s (%s)\n", result.Local, result.Key, labels[result.Status])
+		fmt.Printf("    %s\n", result.URL)
+	}
+	return nil
+}
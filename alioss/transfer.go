@@ -0,0 +1,314 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// defaultTransferRetries 传输失败时的默认重试次数
+const defaultTransferRetries = 2
+
+// transferProgressInterval 输出进度的间隔
+const transferProgressInterval = 5 * time.Second
+
+// 传输事件的状态
+const (
+	TransferDone     = "done"     // 完成
+	TransferSkipped  = "skipped"  // 跳过（无变化、已存在等）
+	TransferFailed   = "failed"   // 重试后仍失败
+	TransferCanceled = "canceled" // 因中断未完成
+	TransferRetry    = "retry"    // 失败后等待重试
+	TransferProgress = "progress" // 定期的进度汇总
+)
+
+// transferTask 一个传输任务，run返回实际写入的目标（OSS路径或本地路径），跳过时返回空字符串
+// 任务失败后可能被重试，run需要能够重复执行
+type transferTask struct {
+	name string // 输出中显示的名称
+	size int64
+	run  func(ctx context.Context) (string, error)
+
+	attempt *statsAttempt // 最后一次尝试的统计，任务结束时计入
+}
+
+// TransferEvent 调度器在任务状态变化和定期汇总时发出的事件
+type TransferEvent struct {
+	Status  string
+	Name    string
+	Target  string
+	Bytes   int64
+	Err     error
+	Attempt int           // 重试事件中已失败的次数
+	Retries int           // 最多重试的次数
+	Delay   time.Duration // 重试事件中等待的时间
+	Result  transferResult
+}
+
+// transferResult 一批传输的结果汇总
+type transferResult struct {
+	Total    int
+	Done     int
+	Skipped  int
+	Failed   int
+	Canceled int
+	Bytes    int64 // 完成的任务的字节数
+}
+
+// err 有任务中断或失败时返回错误，verb为上传、下载等
+func (r transferResult) err(verb string) error {
+	if r.Canceled > 0 {
+		return canceledError(r.Done, r.Canceled)
+	}
+	if r.Failed > 0 {
+		return fmt.Errorf("部分文件%s失败 (%d/%d)", verb, r.Failed, r.Total)
+	}
+	return nil
+}
+
+// transferScheduler 上传、下载和复制共用的调度器，负责排队、并发数限制、重试、进度、中断和结果汇总
+// 任务可以边提交边执行，提交完成后调用wait等待全部结束
+type transferScheduler struct {
+	ctx     context.Context
+	retries int
+	onEvent func(event TransferEvent)
+
+	queue    chan *transferTask
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	result   transferResult
+	stopTick chan struct{}
+}
+
+// newTransferScheduler 创建调度器并启动工作协程
+// verb用于输出，如上传、下载；retries为0时使用默认值，负数表示不重试；onEvent为nil时使用printTransferEvent输出
+func newTransferScheduler(ctx context.Context, verb string, workerCount, retries int, onEvent func(event TransferEvent)) *transferScheduler {
+	if workerCount <= 0 {
+		workerCount = 10
+	}
+	if retries == 0 {
+		retries = defaultTransferRetries
+	}
+	if retries < 0 {
+		retries = 0
+	}
+
+	s := &transferScheduler{
+		ctx:      ctx,
+		retries:  retries,
+		onEvent:  onEvent,
+		queue:    make(chan *transferTask, workerCount*2),
+		stopTick: make(chan struct{}),
+	}
+	if s.onEvent == nil {
+		s.onEvent = func(event TransferEvent) { printTransferEvent(verb, event) }
+	}
+
+	for i := 0; i < workerCount; i++ {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			for task := range s.queue {
+				s.execute(task)
+			}
+		}()
+	}
+
+	go func() {
+		ticker := time.NewTicker(transferProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.emit(TransferEvent{Status: TransferProgress})
+			case <-s.stopTick:
+				return
+			}
+		}
+	}()
+
+	return s
+}
+
+// submit 提交任务，队列已满时等待；中断后的任务直接计为未完成
+func (s *transferScheduler) submit(task *transferTask) {
+	s.mu.Lock()
+	s.result.Total++
+	s.mu.Unlock()
+
+	select {
+	case s.queue <- task:
+	case <-s.ctx.Done():
+		s.finish(task, TransferCanceled, "", nil)
+	}
+}
+
+// wait 等待所有已提交的任务结束并返回结果汇总，之后不能再提交任务
+func (s *transferScheduler) wait() transferResult {
+	close(s.queue)
+	s.wg.Wait()
+	close(s.stopTick)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.result
+}
+
+// execute 执行任务，可重试的错误按1s、2s、4s...的间隔重试
+func (s *transferScheduler) execute(task *transferTask) {
+	delay := time.Second
+	for attempt := 0; ; attempt++ {
+		if s.ctx.Err() != nil {
+			s.finish(task, TransferCanceled, "", nil)
+			return
+		}
+
+		task.attempt = &statsAttempt{}
+		target, err := task.run(context.WithValue(s.ctx, statsAttemptKey{}, task.attempt))
+		switch {
+		case err == nil && target == "":
+			s.finish(task, TransferSkipped, "", nil)
+			return
+		case err == nil:
+			s.finish(task, TransferDone, target, nil)
+			return
+		case s.ctx.Err() != nil:
+			s.finish(task, TransferCanceled, "", nil)
+			return
+		case attempt >= s.retries || !isRetryable(err):
+			s.finish(task, TransferFailed, "", err)
+			return
+		}
+
+		s.emit(TransferEvent{Status: TransferRetry, Name: task.name, Err: err, Attempt: attempt + 1, Retries: s.retries, Delay: delay})
+		select {
+		case <-time.After(delay):
+		case <-s.ctx.Done():
+		}
+		delay *= 2
+	}
+}
+
+// finish 记录任务结果并发出事件
+func (s *transferScheduler) finish(task *transferTask, status, target string, err error) {
+	task.attempt.commit()
+	s.mu.Lock()
+	switch status {
+	case TransferDone:
+		s.result.Done++
+		s.result.Bytes += task.size
+	case TransferSkipped:
+		s.result.Skipped++
+	case TransferFailed:
+		s.result.Failed++
+	case TransferCanceled:
+		s.result.Canceled++
+	}
+	s.mu.Unlock()
+
+	s.emit(TransferEvent{Status: status, Name: task.name, Target: target, Bytes: task.size, Err: err})
+}
+
+// emit 发出事件，事件处理函数不会被并发调用
+func (s *transferScheduler) emit(event TransferEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	event.Result = s.result
+	s.onEvent(event)
+}
+
+// printTransferEvent 默认的事件输出，verb为上传、下载等
+func printTransferEvent(verb string, event TransferEvent) {
+	switch event.Status {
+	case TransferDone:
+		fmt.Printf("已%s: %s\n", verb, event.Target)
+	case TransferFailed:
+		fmt.Fprintf(os.Stderr, "%s失败: %s - %v\n", verb, event.Name, event.Err)
+	case TransferRetry:
+		fmt.Fprintf(os.Stderr, "%s失败，%v后重试 (%d/%d): %s - %v\n", verb, event.Delay, event.Attempt, event.Retries, event.Name, event.Err)
+	case TransferProgress:
+		r := event.Result
+		fmt.Printf("%s中: 已完成 %d/%d 个文件 (%s)", verb, r.Done+r.Skipped+r.Failed, r.Total, formatSize(r.Bytes))
+		if r.Failed > 0 {
+			fmt.Printf("，%d 个失败", r.Failed)
+		}
+		fmt.Println()
+	}
+}
+
+// isRetryable 判断错误是否可以重试：网络错误、CRC校验失败、HTTP 429和5xx
+// 本地文件错误和其它服务端错误（如权限不足、文件已存在）重试也不会成功
+func isRetryable(err error) bool {
+	var serviceErr oss.ServiceError
+	if errors.As(err, &serviceErr) {
+		return serviceErr.StatusCode == http.StatusTooManyRequests || serviceErr.StatusCode >= 500
+	}
+	var crcErr oss.CRCCheckError
+	if errors.As(err, &crcErr) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// printTransferResult 输出传输结果汇总，不换行，调用方可以继续追加内容
+func printTransferResult(verb string, r transferResult) {
+	if r.Canceled > 0 {
+		fmt.Printf("\n%s已中断: %d 个文件成功", verb, r.Done)
+	} else {
+		fmt.Printf("\n%s完成: %d 个文件成功", verb, r.Done)
+	}
+	if r.Bytes > 0 {
+		fmt.Printf(" (%s)", formatSize(r.Bytes))
+	}
+	if r.Failed > 0 {
+		fmt.Printf("，%d 个文件失败", r.Failed)
+	}
+	if r.Skipped > 0 {
+		fmt.Printf("，%d 个文件被跳过", r.Skipped)
+	}
+	if r.Canceled > 0 {
+		fmt.Printf("，%d 个文件未%s", r.Canceled, verb)
+	}
+}
+
+// flagRetries 把 --retries 选项转换为调度器的重试次数，未指定时为0（使用默认值），指定0时为-1（不重试）
+// 选项的值已由checkFlagValue检查
+func flagRetries(p *parsedArgs) int {
+	if !p.has("retries") {
+		return 0
+	}
+	if n := p.int("retries", 0); n > 0 {
+		return n
+	}
+	return -1
+}
+
+// runTransfer 通过调度器执行单个任务，与批量传输使用相同的重试和中断处理，返回run的结果
+func runTransfer(ctx context.Context, verb string, retries int, task *transferTask) (string, error) {
+	var target string
+	var taskErr error
+	scheduler := newTransferScheduler(ctx, verb, 1, retries, func(event TransferEvent) {
+		switch event.Status {
+		case TransferDone:
+			target = event.Target
+		case TransferFailed:
+			taskErr = event.Err
+		case TransferRetry:
+			printTransferEvent(verb, event)
+		}
+	})
+	scheduler.submit(task)
+	if result := scheduler.wait(); result.Canceled > 0 {
+		return "", fmt.Errorf("%s已中断: %s", verb, task.name)
+	}
+	return target, taskErr
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+const (
+	// defaultTrashPrefix 默认的回收站前缀
+	defaultTrashPrefix = ".trash/"
+	// trashIDLayout 回收站批次ID的时间格式（UTC）
+	trashIDLayout = "20060102-150405.000"
+	// maxCopyObjectSize 超过该大小的文件使用分片拷贝
+	maxCopyObjectSize int64 = 1024 * 1024 * 1024
+	// copyPartSize 分片拷贝的分片大小
+	copyPartSize int64 = 100 * 1024 * 1024
+)
+
+// TrashBatch 回收站中一次删除操作的记录
+type TrashBatch struct {
+	ID      string
+	Time    time.Time
+	Count   int
+	Size    int64
+	Samples []string // 部分原始路径，用于显示
+}
+
+// trashPrefix 回收站前缀，确保以斜杠结尾
+func (c *OSSClient) trashPrefix() string {
+	prefix := strings.TrimPrefix(c.config.TrashPrefix, "/")
+	if prefix == "" {
+		prefix = defaultTrashPrefix
+	}
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return prefix
+}
+
+// Remove 删除文件或前缀，配置中开启回收站时移动到回收站
+func (c *OSSClient) Remove(ossPath string) error {
+	if c.config.Trash {
+		_, err := c.MoveToTrash(ossPath, nil, nil)
+		return err
+	}
+	return c.DeleteFile(context.Background(), ossPath, 1)
+}
+
+// copyObject 在Bucket内拷贝文件，大文件使用分片拷贝
+func (c *OSSClient) copyObject(srcKey, destKey string, size int64) error {
+	if size > maxCopyObjectSize {
+		// 分片拷贝不会自动复制元数据和标签
+		meta, err := c.bucket.GetObjectDetailedMeta(srcKey)
+		if err != nil {
+			return fmt.Errorf("获取源文件信息失败: %v", err)
+		}
+		options := objectMetaOptions(meta)
+		tagging, err := c.bucket.GetObjectTagging(srcKey)
+		if err != nil {
+			return fmt.Errorf("获取源文件标签失败: %v", err)
+		}
+		if len(tagging.Tags) > 0 {
+			options = append(options, oss.SetTagging(oss.Tagging{Tags: tagging.Tags}))
+		}
+		return c.bucket.CopyFile(c.config.Bucket, srcKey, destKey, copyPartSize, options...)
+	}
+	_, err := c.bucket.CopyObject(srcKey, destKey)
+	return err
+}
+
+// moveObject 服务端拷贝后删除源文件
+func (c *OSSClient) moveObject(srcKey, destKey string, size int64) error {
+	err := c.copyObject(srcKey, destKey, size)
+	if err != nil {
+		err = fmt.Errorf("拷贝失败: %v", err)
+	} else if err = c.bucket.DeleteObject(srcKey); err != nil {
+		err = fmt.Errorf("删除源文件失败: %v", err)
+	}
+	c.audit(AuditEntry{Op: AuditMove, Keys: []string{srcKey}, Dest: destKey, Bytes: size}, err)
+	return err
+}
+
+// MoveToTrash 将文件或前缀下的文件（可按标签、时间和大小筛选）移动到回收站，返回回收站批次ID
+func (c *OSSClient) MoveToTrash(ossPath string, filters []TagFilter, transferFilter *TransferFilter) (string, error) {
+	// 标准化OSS路径，去除前导斜杠
+	ossPath = strings.TrimPrefix(ossPath, "/")
+	trashPrefix := c.trashPrefix()
+
+	var objects []oss.ObjectProperties
+	if ossPath != "" && !strings.HasSuffix(ossPath, "/") && !strings.Contains(ossPath, "*") {
+		meta, err := c.bucket.GetObjectDetailedMeta(ossPath)
+		if err != nil {
+			return "", fmt.Errorf("获取文件信息失败: %v", err)
+		}
+		var size int64
+		fmt.Sscanf(meta.Get(oss.HTTPHeaderContentLength), "%d", &size)
+		modTime, _ := time.Parse(time.RFC1123, meta.Get(oss.HTTPHeaderLastModified))
+		objects = append(objects, oss.ObjectProperties{Key: ossPath, Size: size, LastModified: modTime})
+	} else {
+		prefix := strings.Split(ossPath, "*")[0]
+		listed, err := c.ListObjects(prefix)
+		if err != nil {
+			return "", fmt.Errorf("获取文件列表失败: %v", err)
+		}
+		for _, object := range listed {
+			// 不把回收站里的文件再放进回收站
+			if !strings.HasPrefix(object.Key, trashPrefix) {
+				objects = append(objects, object)
+			}
+		}
+	}
+
+	objects = filterObjects(objects, transferFilter)
+
+	if len(filters) > 0 {
+		keys := make([]string, len(objects))
+		for i, object := range objects {
+			keys[i] = object.Key
+		}
+		allTags, err := c.FetchTags(keys, 0)
+		if err != nil {
+			return "", err
+		}
+		var matched []oss.ObjectProperties
+		for _, object := range objects {
+			if matchTags(allTags[object.Key], filters) {
+				matched = append(matched, object)
+			}
+		}
+		objects = matched
+	}
+
+	if len(objects) == 0 {
+		return "", fmt.Errorf("未找到匹配的文件")
+	}
+
+	id := time.Now().UTC().Format(trashIDLayout)
+	batchPrefix := trashPrefix + id + "/"
+
+	moved := 0
+	for _, object := range objects {
+		if err := c.moveObject(object.Key, batchPrefix+object.Key, object.Size); err != nil {
+			return id, fmt.Errorf("移动 %s 到回收站失败（已移动 %d 个文件）: %v", object.Key, moved, err)
+		}
+		moved++
+		fmt.Printf("已移到回收站: %s\n", object.Key)
+	}
+
+	fmt.Printf("已将 %d 个文件移到回收站，批次ID: %s\n", moved, id)
+	fmt.Printf("可以使用 alioss trash restore %s 恢复\n", id)
+	return id, nil
+}
+
+// ListTrash 列出回收站中的批次，按时间从新到旧排序
+func (c *OSSClient) ListTrash() ([]*TrashBatch, error) {
+	trashPrefix := c.trashPrefix()
+	objects, err := c.ListObjects(trashPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	batches := make(map[string]*TrashBatch)
+	for _, object := range objects {
+		id, key, ok := strings.Cut(strings.TrimPrefix(object.Key, trashPrefix), "/")
+		if !ok || key == "" {
+			continue
+		}
+
+		batch, exists := batches[id]
+		if !exists {
+			batch = &TrashBatch{ID: id}
+			batch.Time, _ = time.Parse(trashIDLayout, id)
+			batches[id] = batch
+		}
+		batch.Count++
+		batch.Size += object.Size
+		if len(batch.Samples) < 3 {
+			batch.Samples = append(batch.Samples, key)
+		}
+	}
+
+	result := make([]*TrashBatch, 0, len(batches))
+	for _, batch := range batches {
+		result = append(result, batch)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID > result[j].ID })
+	return result, nil
+}
+
+// RestoreTrash 将回收站批次中的文件恢复到原位置，原位置已有文件时跳过，force为true时覆盖
+func (c *OSSClient) RestoreTrash(id string, force bool) error {
+	if id == "" || strings.Contains(id, "/") {
+		return fmt.Errorf("无效的批次ID: %s", id)
+	}
+	batchPrefix := c.trashPrefix() + id + "/"
+
+	objects, err := c.ListObjects(batchPrefix)
+	if err != nil {
+		return err
+	}
+	if len(objects) == 0 {
+		return fmt.Errorf("回收站中没有批次: %s", id)
+	}
+
+	restored, skipped := 0, 0
+	for _, object := range objects {
+		key := strings.TrimPrefix(object.Key, batchPrefix)
+		if key == "" {
+			continue
+		}
+
+		if !force {
+			exist, err := c.bucket.IsObjectExist(key)
+			if err != nil {
+				return fmt.Errorf("检查文件 %s 失败: %v", key, err)
+			}
+			if exist {
+				fmt.Printf("跳过(已存在): %s\n", key)
+				skipped++
+				continue
+			}
+		}
+
+		if err := c.moveObject(object.Key, key, object.Size); err != nil {
+			return fmt.Errorf("恢复 %s 失败（已恢复 %d 个文件）: %v", key, restored, err)
+		}
+		restored++
+		fmt.Printf("已恢复: %s\n", key)
+	}
+
+	fmt.Printf("成功恢复 %d 个文件", restored)
+	if skipped > 0 {
+		fmt.Printf("，%d 个文件因原位置已存在而跳过（使用 --force 覆盖）", skipped)
+	}
+	fmt.Println()
+	return nil
+}
+
+// EmptyTrash 永久删除回收站中早于olderThan的批次，olderThan为0时清空回收站
+func (c *OSSClient) EmptyTrash(olderThan time.Duration) error {
+	batches, err := c.ListTrash()
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	var keys []string
+	batchCount := 0
+	for _, batch := range batches {
+		if olderThan > 0 && (batch.Time.IsZero() || !batch.Time.Before(cutoff)) {
+			continue
+		}
+
+		objects, err := c.ListObjects(c.trashPrefix() + batch.ID + "/")
+		if err != nil {
+			return err
+		}
+		for _, object := range objects {
+			keys = append(keys, object.Key)
+		}
+		batchCount++
+		fmt.Printf("清理批次: %s (%d 个文件)\n", batch.ID, batch.Count)
+	}
+
+	if len(keys) == 0 {
+		fmt.Println("回收站中没有需要清理的文件")
+		return nil
+	}
+
+	if err := c.DeleteObjectsBatch(keys); err != nil {
+		return err
+	}
+	fmt.Printf("已永久删除 %d 个批次共 %d 个文件\n", batchCount, len(keys))
+	return nil
+}
+
+// printTrash 输出回收站批次列表
+func printTrash(batches []*TrashBatch) {
+	if len(batches) == 0 {
+		fmt.Println("回收站为空")
+		return
+	}
+
+	for _, batch := range batches {
+		deletedAt := batch.ID
+		if !batch.Time.IsZero() {
+			deletedAt = batch.Time.Local().Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("%s  %s  %d 个文件  %s\n", batch.ID, deletedAt, batch.Count, formatSize(batch.Size))
+		for _, sample := range batch.Samples {
+			fmt.Printf("    %s\n", sample)
+		}
+		if batch.Count > len(batch.Samples) {
+			fmt.Printf("    ...\n")
+		}
+	}
+}
+
+// trashCommand 处理 trash 子命令
+func trashCommand(client *OSSClient, p *parsedArgs) error {
+	switch p.arg(0) {
+	case "list":
+		batches, err := client.ListTrash()
+		if err != nil {
+			return err
+		}
+		printTrash(batches)
+		return nil
+
+	case "restore":
+		if p.arg(1) == "" {
+			return fmt.Errorf("用法: alioss trash restore <批次ID> [--force]")
+		}
+		return client.RestoreTrash(p.arg(1), p.has("force"))
+
+	case "empty":
+		var olderThan time.Duration
+		if p.has("older-than") {
+			d, err := parseAge(p.str("older-than", ""))
+			if err != nil {
+				return err
+			}
+			olderThan = d
+		}
+		return client.EmptyTrash(olderThan)
+
+	default:
+		return fmt.Errorf("未知的回收站操作: %s，可选 list、restore、empty", p.arg(0))
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// ObjectVersion 表示文件的一个历史版本或删除标记
+type ObjectVersion struct {
+	Key            string
+	VersionID      string
+	IsLatest       bool
+	IsDeleteMarker bool
+	Size           int64
+	ETag           string
+	LastModified   time.Time
+}
+
+// ListVersions 列出指定前缀下所有文件的历史版本和删除标记
+// 结果按Key排序，同一Key的版本按从新到旧排列
+func (c *OSSClient) ListVersions(prefix string) ([]ObjectVersion, error) {
+	// 标准化前缀，去除前导斜杠
+	prefix = strings.TrimPrefix(prefix, "/")
+
+	keyMarker := ""
+	versionIDMarker := ""
+	var versions []ObjectVersion
+
+	for {
+		lsRes, err := c.bucket.ListObjectVersions(
+			oss.Prefix(prefix),
+			oss.KeyMarker(keyMarker),
+			oss.VersionIdMarker(versionIDMarker),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("列举文件版本失败: %v", err)
+		}
+
+		for _, v := range lsRes.ObjectVersions {
+			versions = append(versions, ObjectVersion{
+				Key:          v.Key,
+				VersionID:    v.VersionId,
+				IsLatest:     v.IsLatest,
+				Size:         v.Size,
+				ETag:         strings.Trim(v.ETag, "\""),
+				LastModified: v.LastModified,
+			})
+		}
+		for _, m := range lsRes.ObjectDeleteMarkers {
+			versions = append(versions, ObjectVersion{
+				Key:            m.Key,
+				VersionID:      m.VersionId,
+				IsLatest:       m.IsLatest,
+				IsDeleteMarker: true,
+				LastModified:   m.LastModified,
+			})
+		}
+
+		if lsRes.IsTruncated {
+			keyMarker = lsRes.NextKeyMarker
+			versionIDMarker = lsRes.NextVersionIdMarker
+		} else {
+			break
+		}
+	}
+
+	// 版本和删除标记在返回结果中是分开的，需要合并排序
+	sort.SliceStable(versions, func(i, j int) bool {
+		if versions[i].Key != versions[j].Key {
+			return versions[i].Key < versions[j].Key
+		}
+		if versions[i].IsLatest != versions[j].IsLatest {
+			return versions[i].IsLatest
+		}
+		return versions[i].LastModified.After(versions[j].LastModified)
+	})
+
+	return versions, nil
+}
+
+// DeleteFileVersion 永久删除文件的指定版本（也可用于删除某个删除标记）
+func (c *OSSClient) DeleteFileVersion(ossPath, versionID string) error {
+	// 标准化OSS路径，去除前导斜杠
+	ossPath = strings.TrimPrefix(ossPath, "/")
+
+	err := c.bucket.DeleteObject(ossPath, oss.VersionId(versionID))
+	c.audit(AuditEntry{Op: AuditDelete, Keys: []string{ossPath}, VersionID: versionID}, err)
+	if err != nil {
+		return fmt.Errorf("删除文件版本失败: %v", err)
+	}
+
+	return nil
+}
+
+// Undelete 通过移除删除标记恢复被删除的文件
+// 以斜杠结尾时恢复该前缀下的所有文件，否则只恢复指定文件
+func (c *OSSClient) Undelete(ossPath string) error {
+	// 标准化OSS路径，去除前导斜杠
+	ossPath = strings.TrimPrefix(ossPath, "/")
+	isPrefix := ossPath == "" || strings.HasSuffix(ossPath, "/")
+
+	versions, err := c.ListVersions(ossPath)
+	if err != nil {
+		return err
+	}
+
+	// 收集每个文件最新的非删除版本之前的所有删除标记
+	var markers []oss.DeleteObject
+	var restoredKeys []string
+	var noVersionCount int
+
+	for i := 0; i < len(versions); {
+		key := versions[i].Key
+		j := i
+		for j < len(versions) && versions[j].Key == key {
+			j++
+		}
+
+		if isPrefix || key == ossPath {
+			group := versions[i:j]
+			if group[0].IsDeleteMarker {
+				var keyMarkers []oss.DeleteObject
+				restorable := false
+				for _, v := range group {
+					if !v.IsDeleteMarker {
+						restorable = true
+						break
+					}
+					keyMarkers = append(keyMarkers, oss.DeleteObject{Key: v.Key, VersionId: v.VersionID})
+				}
+				if restorable {
+					markers = append(markers, keyMarkers...)
+					restoredKeys = append(restoredKeys, key)
+				} else {
+					noVersionCount++
+					fmt.Printf("无可恢复版本: %s\n", key)
+				}
+			}
+		}
+
+		i = j
+	}
+
+	if len(restoredKeys) == 0 {
+		if noVersionCount > 0 {
+			return fmt.Errorf("没有可恢复的文件")
+		}
+		return fmt.Errorf("未找到被删除的文件")
+	}
+
+	// DeleteObjectVersions每次最多删除1000个
+	const batchSize = 1000
+	for start := 0; start < len(markers); start += batchSize {
+		end := start + batchSize
+		if end > len(markers) {
+			end = len(markers)
+		}
+		batch := markers[start:end]
+		_, err := c.bucket.DeleteObjectVersions(batch, oss.DeleteObjectsQuiet(true))
+		keys := make([]string, len(batch))
+		for i, marker := range batch {
+			keys[i] = marker.Key
+		}
+		c.audit(AuditEntry{Op: AuditUndelete, Keys: keys}, err)
+		if err != nil {
+			return fmt.Errorf("移除删除标记失败: %v", err)
+		}
+	}
+
+	for _, key := range restoredKeys {
+		fmt.Printf("已恢复: %s\n", key)
+	}
+	fmt.Printf("成功恢复 %d 个文件\n", len(restoredKeys))
+	return nil
+}
+
+// filterVersions 按修改时间和大小筛选版本，删除标记按大小为0处理
+func filterVersions(versions []ObjectVersion, filter *TransferFilter) []ObjectVersion {
+	if filter == nil {
+		return versions
+	}
+	var matched []ObjectVersion
+	for _, v := range versions {
+		if filter.match(v.LastModified, v.Size) {
+			matched = append(matched, v)
+		}
+	}
+	return matched
+}
+
+// printVersions 按文件分组输出版本列表
+func printVersions(versions []ObjectVersion) {
+	if len(versions) == 0 {
+		fmt.Println("未找到文件")
+		return
+	}
+
+	fmt.Println("文件版本列表:")
+	lastKey := ""
+	for _, v := range versions {
+		if v.Key != lastKey {
+			fmt.Println("  " + v.Key)
+			lastKey = v.Key
+		}
+
+		flag := ""
+		if v.IsLatest {
+			flag = " [最新]"
+		}
+		modified := v.LastModified.Local().Format("2006-01-02 15:04:05")
+		if v.IsDeleteMarker {
+			fmt.Printf("    %s  %s  删除标记%s\n", v.VersionID, modified, flag)
+		} else {
+			fmt.Printf("    %s  %s  %d 字节%s\n", v.VersionID, modified, v.Size, flag)
+		}
+	}
+}
@@ -0,0 +1,455 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// WatchOptions 监听目录选项
+type WatchOptions struct {
+	UploadOptions                // 排除模式、增量上传、符号链接等上传选项
+	Delete         bool          // 本地删除文件后是否同步删除OSS上的文件
+	Debounce       time.Duration // 文件最后一次变化后等待多久再上传
+	RescanInterval time.Duration // 定期全量扫描的间隔，0表示不扫描
+}
+
+// fileState 记录已同步文件的状态，用于判断文件是否再次变化
+type fileState struct {
+	size  int64
+	mtime time.Time
+	mode  os.FileMode
+}
+
+// dirWatcher 监听本地目录并将变化同步到OSS
+type dirWatcher struct {
+	client  *OSSClient
+	root    string
+	prefix  string
+	options *WatchOptions
+	fsw     *fsnotify.Watcher
+
+	mu      sync.Mutex
+	pending map[string]time.Time // 等待处理的路径及其最后一次变化时间
+	dirs    map[string]bool      // 已添加监听的目录
+	synced  map[string]fileState // 已同步的文件，key为相对路径
+
+	uploadCount, deleteCount, errorCount int
+}
+
+// Watch 持续监听本地目录，将新建和修改的文件上传到OSS，直到stop被关闭
+// 启动时先进行一次全量同步，之后按options.RescanInterval定期全量扫描以弥补遗漏的事件
+func (c *OSSClient) Watch(localDirPath, ossDirPath string, options *WatchOptions, stop <-chan struct{}) error {
+	if options == nil {
+		options = &WatchOptions{}
+	}
+	if options.Debounce <= 0 {
+		options.Debounce = 2 * time.Second
+	}
+	if options.WorkerCount <= 0 {
+		options.WorkerCount = 10
+	}
+
+	// 确保OSS路径以斜杠结尾
+	if ossDirPath != "" && !strings.HasSuffix(ossDirPath, "/") {
+		ossDirPath += "/"
+	}
+
+	// 标准化OSS路径，去除前导斜杠
+	ossDirPath = strings.TrimPrefix(ossDirPath, "/")
+
+	absLocalDirPath, err := filepath.Abs(localDirPath)
+	if err != nil {
+		return fmt.Errorf("获取绝对路径失败: %v", err)
+	}
+
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("创建文件监听失败: %v", err)
+	}
+	defer fsw.Close()
+
+	w := &dirWatcher{
+		client:  c,
+		root:    absLocalDirPath,
+		prefix:  ossDirPath,
+		options: options,
+		fsw:     fsw,
+		pending: make(map[string]time.Time),
+		dirs:    make(map[string]bool),
+		synced:  make(map[string]fileState),
+	}
+
+	fmt.Printf("开始监听目录: %s 到 %s\n", absLocalDirPath, ossDirPath)
+	if len(options.ExcludePatterns) > 0 {
+		fmt.Printf("使用 %d 个排除模式\n", len(options.ExcludePatterns))
+	}
+	if options.Delete {
+		fmt.Println("本地删除的文件将同步从OSS删除")
+	}
+
+	// 先添加监听再扫描，避免遗漏扫描期间的变化
+	if err := w.addWatches(absLocalDirPath); err != nil {
+		return fmt.Errorf("添加目录监听失败: %v", err)
+	}
+	w.rescan()
+	fmt.Println("初始同步完成，等待文件变化...")
+
+	// 事件接收协程，只记录路径，由主循环统一处理
+	go func() {
+		for {
+			select {
+			case event, ok := <-fsw.Events:
+				if !ok {
+					return
+				}
+				w.mu.Lock()
+				w.pending[event.Name] = time.Now()
+				w.mu.Unlock()
+			case err, ok := <-fsw.Errors:
+				if !ok {
+					return
+				}
+				fmt.Fprintf(os.Stderr, "监听错误: %v\n", err)
+			}
+		}
+	}()
+
+	tick := options.Debounce / 2
+	if tick < 100*time.Millisecond {
+		tick = 100 * time.Millisecond
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	var rescanChan <-chan time.Time
+	if options.RescanInterval > 0 {
+		rescanTicker := time.NewTicker(options.RescanInterval)
+		defer rescanTicker.Stop()
+		rescanChan = rescanTicker.C
+	}
+
+	for {
+		select {
+		case <-ticker.C:
+			w.processPending(false)
+		case <-rescanChan:
+			fmt.Println("开始定期全量扫描...")
+			w.rescan()
+		case <-stop:
+			fmt.Println("\n正在停止监听，处理剩余的变化...")
+			w.processPending(true)
+			fmt.Printf("监听已停止: 上传 %d 个文件", w.uploadCount)
+			if w.deleteCount > 0 {
+				fmt.Printf(", 删除 %d 个文件", w.deleteCount)
+			}
+			if w.errorCount > 0 {
+				fmt.Printf(", %d 次失败", w.errorCount)
+			}
+			fmt.Println()
+			return nil
+		}
+	}
+}
+
+// addWatches 递归地为目录及其子目录添加监听
+func (w *dirWatcher) addWatches(dir string) error {
+	return w.addWatchesIn(dir, make(map[string]bool))
+}
+
+// addWatchesIn 为目录添加监听，ancestors记录当前路径上的真实目录以检测链接循环
+func (w *dirWatcher) addWatchesIn(dir string, ancestors map[string]bool) error {
+	realDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return err
+	}
+	if ancestors[realDir] {
+		return nil
+	}
+	ancestors[realDir] = true
+	defer delete(ancestors, realDir)
+
+	w.mu.Lock()
+	watched := w.dirs[dir]
+	w.dirs[dir] = true
+	w.mu.Unlock()
+	if !watched {
+		if err := w.fsw.Add(dir); err != nil {
+			return err
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		isDir := entry.IsDir()
+		// 跟随指向目录的符号链接
+		if entry.Type()&os.ModeSymlink != 0 && w.followSymlinks() {
+			if info, err := os.Stat(path); err == nil && info.IsDir() {
+				isDir = true
+			}
+		}
+		if isDir {
+			if err := w.addWatchesIn(path, ancestors); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// followSymlinks 是否跟随符号链接
+func (w *dirWatcher) followSymlinks() bool {
+	return w.options.Symlinks == "" || w.options.Symlinks == SymlinkFollow
+}
+
+// processPending 处理已稳定的变化，force为true时处理全部变化
+func (w *dirWatcher) processPending(force bool) {
+	now := time.Now()
+	var ready []string
+
+	w.mu.Lock()
+	for path, last := range w.pending {
+		if force || now.Sub(last) >= w.options.Debounce {
+			ready = append(ready, path)
+			delete(w.pending, path)
+		}
+	}
+	w.mu.Unlock()
+
+	if len(ready) == 0 {
+		return
+	}
+
+	scheduler := w.newScheduler()
+	for _, path := range ready {
+		w.processPath(scheduler, path)
+	}
+	scheduler.wait()
+}
+
+// newScheduler 创建上传调度器，事件按监听的格式输出并计数
+// 停止监听时仍要上传剩余的变化，因此不随中断信号取消
+func (w *dirWatcher) newScheduler() *transferScheduler {
+	return newTransferScheduler(context.Background(), "上传", w.options.WorkerCount, w.options.Retries, func(event TransferEvent) {
+		switch event.Status {
+		case TransferDone:
+			w.mu.Lock()
+			w.uploadCount++
+			w.mu.Unlock()
+			fmt.Printf("[%s] 已上传: %s\n", time.Now().Format("15:04:05"), event.Target)
+		case TransferFailed:
+			w.reportError(event.Name, event.Err)
+		case TransferRetry:
+			fmt.Fprintf(os.Stderr, "[%s] 上传失败，%v后重试 (%d/%d): %s - %v\n", time.Now().Format("15:04:05"), event.Delay, event.Attempt, event.Retries, event.Name, event.Err)
+		}
+	})
+}
+
+// processPath 根据路径当前的状态提交上传、删除或开始监听新目录
+func (w *dirWatcher) processPath(scheduler *transferScheduler, path string) {
+	rel, err := filepath.Rel(w.root, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return
+	}
+	rel = filepath.ToSlash(rel)
+
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		w.handleRemoved(path, rel)
+		return
+	}
+	if err != nil {
+		w.reportError(rel, err)
+		return
+	}
+
+	file := &localFile{path: path, relPath: rel, info: info}
+	if info.Mode()&os.ModeSymlink != 0 {
+		switch w.options.Symlinks {
+		case SymlinkSkip:
+			return
+		case SymlinkStore:
+			file.linkTarget, err = os.Readlink(path)
+			if err != nil {
+				w.reportError(rel, err)
+				return
+			}
+		default:
+			file.info, err = os.Stat(path)
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	if file.linkTarget == "" && file.info.IsDir() {
+		// 新目录：添加监听并同步其中已有的文件
+		if err := w.addWatches(path); err != nil {
+			w.reportError(rel, err)
+		}
+		// 排除模式是相对于监听根目录的，因此遍历时不排除，而是按完整相对路径检查
+		walkOptions := w.options.UploadOptions
+		walkOptions.ExcludePatterns = nil
+		_, err := walkLocalDir(path, &walkOptions, func(sub *localFile) error {
+			sub.relPath = rel + "/" + sub.relPath
+			if !shouldExclude(sub.relPath, &w.options.UploadOptions) && !shouldExclude(sub.path, &w.options.UploadOptions) {
+				w.syncFile(scheduler, sub)
+			}
+			return nil
+		})
+		if err != nil {
+			w.reportError(rel, err)
+		}
+		return
+	}
+
+	if file.linkTarget == "" && !file.info.Mode().IsRegular() {
+		return
+	}
+
+	// 检查文件是否被排除
+	if shouldExclude(rel, &w.options.UploadOptions) || shouldExclude(path, &w.options.UploadOptions) {
+		return
+	}
+
+	w.syncFile(scheduler, file)
+}
+
+// syncFile 文件状态发生变化时提交上传任务
+func (w *dirWatcher) syncFile(scheduler *transferScheduler, file *localFile) {
+	state := fileState{size: file.info.Size(), mtime: file.info.ModTime(), mode: file.info.Mode()}
+
+	w.mu.Lock()
+	old, known := w.synced[file.relPath]
+	w.mu.Unlock()
+	if known && old == state {
+		return
+	}
+
+	ossObjectPath := w.prefix + file.relPath
+	scheduler.submit(&transferTask{
+		name: file.relPath,
+		size: file.info.Size(),
+		run: func(ctx context.Context) (string, error) {
+			// 增量模式下首次见到的文件先比较内容，避免重复上传
+			if !known && w.options.Incremental {
+				needUpload, err := w.client.needUploadLocal(file, ossObjectPath)
+				if err != nil {
+					return "", err
+				}
+				if !needUpload {
+					w.markSynced(file.relPath, state)
+					return "", nil
+				}
+			}
+
+			if err := w.client.putLocalFile(ctx, file, ossObjectPath, &w.options.UploadOptions); err != nil {
+				return "", err
+			}
+			w.markSynced(file.relPath, state)
+			return ossObjectPath, nil
+		},
+	})
+}
+
+// markSynced 记录文件已同步时的状态
+func (w *dirWatcher) markSynced(rel string, state fileState) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.synced[rel] = state
+}
+
+// handleRemoved 处理被删除或移走的文件和目录
+func (w *dirWatcher) handleRemoved(path, rel string) {
+	var removed []string
+
+	w.mu.Lock()
+	// 被删除的目录不再需要监听
+	for dir := range w.dirs {
+		if dir == path || strings.HasPrefix(dir, path+string(filepath.Separator)) {
+			delete(w.dirs, dir)
+		}
+	}
+	for key := range w.synced {
+		if key == rel || strings.HasPrefix(key, rel+"/") {
+			removed = append(removed, key)
+			delete(w.synced, key)
+		}
+	}
+	w.mu.Unlock()
+
+	if !w.options.Delete {
+		return
+	}
+
+	// 只删除本进程同步过的文件，不会影响OSS上其它来源的文件
+	for _, key := range removed {
+		ossObjectPath := w.prefix + key
+		err := w.client.bucket.DeleteObject(ossObjectPath)
+		w.client.audit(AuditEntry{Op: AuditDelete, Keys: []string{ossObjectPath}}, err)
+		if err != nil {
+			w.reportError(key, err)
+			continue
+		}
+		w.mu.Lock()
+		w.deleteCount++
+		w.mu.Unlock()
+		fmt.Printf("[%s] 已删除: %s\n", time.Now().Format("15:04:05"), ossObjectPath)
+	}
+}
+
+// rescan 全量扫描本地目录，上传有变化的文件，并处理扫描期间发现的删除
+func (w *dirWatcher) rescan() {
+	if err := w.addWatches(w.root); err != nil {
+		fmt.Fprintf(os.Stderr, "添加目录监听失败: %v\n", err)
+	}
+
+	// 边扫描边上传
+	seen := make(map[string]bool)
+	scheduler := w.newScheduler()
+	_, err := walkLocalDir(w.root, &w.options.UploadOptions, func(file *localFile) error {
+		seen[file.relPath] = true
+		w.syncFile(scheduler, file)
+		return nil
+	})
+	scheduler.wait()
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "扫描目录失败: %v\n", err)
+		// 扫描不完整时不处理删除，避免误删
+		return
+	}
+
+	var missing []string
+	w.mu.Lock()
+	for key := range w.synced {
+		if !seen[key] {
+			missing = append(missing, key)
+		}
+	}
+	w.mu.Unlock()
+
+	for _, key := range missing {
+		w.handleRemoved(filepath.Join(w.root, filepath.FromSlash(key)), key)
+	}
+}
+
+// reportError 输出错误并计数，监听不会因单个文件失败而停止
+func (w *dirWatcher) reportError(rel string, err error) {
+	w.mu.Lock()
+	w.errorCount++
+	w.mu.Unlock()
+	fmt.Fprintf(os.Stderr, "[%s] 同步失败: %s - %v\n", time.Now().Format("15:04:05"), rel, err)
+}